@@ -0,0 +1,16 @@
+data "omdb_csv_films" "diary" {
+  path = "${path.module}/letterboxd/diary.csv"
+}
+
+resource "omdb_film" "watched" {
+  for_each = {
+    for film in data.omdb_csv_films.diary.films :
+    coalesce(film.imdb_id, "${film.title} (${film.year})") => film
+  }
+
+  title = each.value.title
+  year  = each.value.year
+  ratings0 = each.value.user_rating == null ? [] : [
+    { source = "Me", value = "${each.value.user_rating}/10" },
+  ]
+}
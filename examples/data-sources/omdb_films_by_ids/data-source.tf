@@ -0,0 +1,7 @@
+data "omdb_films_by_ids" "favorites" {
+  imdb_ids = ["tt0080455", "tt0088247", "tt0093779"]
+}
+
+output "unresolved" {
+  value = data.omdb_films_by_ids.favorites.errors
+}
@@ -0,0 +1,16 @@
+resource "omdb_film" "brazil" {
+  title  = "Brazil"
+  year   = "1985"
+  tags   = ["dystopia", "satire"]
+  labels = { festival = "2026" }
+}
+
+data "omdb_library_films" "festival" {
+  labels = { festival = "2026" }
+
+  depends_on = [omdb_film.brazil]
+}
+
+output "festival_programme" {
+  value = [for id in data.omdb_library_films.festival.ids : data.omdb_library_films.festival.films[id].title]
+}
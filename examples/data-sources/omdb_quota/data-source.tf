@@ -0,0 +1,5 @@
+data "omdb_quota" "today" {}
+
+output "requests_remaining" {
+  value = data.omdb_quota.today.remaining
+}
@@ -0,0 +1,9 @@
+data "omdb_viewings" "club" {}
+
+output "screenings" {
+  value = data.omdb_viewings.club.total
+}
+
+output "last_watched" {
+  value = { for key, film in data.omdb_viewings.club.films : key => film.last_watched }
+}
@@ -1,4 +1,4 @@
 provider "omdb" {
-  // set with shell command: export TF_VAR_api_key="xxxxxx"
+  // or omit api_key and set with shell command: export OMDB_API_KEY="xxxxxx"
   api_key = var.api_key
 }
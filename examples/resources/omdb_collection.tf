@@ -0,0 +1,18 @@
+resource "omdb_film" "brazil" {
+  title = "Brazil"
+  year  = "1985"
+}
+
+resource "omdb_film" "gattaca" {
+  title = "Gattaca"
+  year  = "1997"
+}
+
+resource "omdb_collection" "dystopias" {
+  name        = "Dystopias"
+  description = "Bleak futures, in viewing order"
+  film_ids = [
+    omdb_film.brazil.id,
+    omdb_film.gattaca.id,
+  ]
+}
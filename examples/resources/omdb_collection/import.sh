@@ -0,0 +1,3 @@
+# Collections are imported by the name of their file in the collections
+# directory of the provider's local_dir
+terraform import omdb_collection.example 1a2b3c4d5e6f7a8b
@@ -0,0 +1,7 @@
+# Films are imported by the name of their file in the provider's local_dir
+terraform import omdb_film.example 1a2b3c4d5e6f7a8b
+
+# To bring a whole existing library under management, generate resource and
+# import blocks for every film file, then plan and apply. Import blocks need
+# Terraform 1.5 or later.
+terraform-provider-omdb generate-config -local-dir /tmp/.omdb > films.tf
@@ -0,0 +1,12 @@
+resource "omdb_film" "brazil" {
+  title = "Brazil"
+  year  = "1985"
+}
+
+resource "omdb_user_rating" "brazil_sam" {
+  film_id  = omdb_film.brazil.id
+  reviewer = "Sam Lowry"
+  score    = 9
+  review   = "Dreamlike, and a warning about paperwork."
+  date     = "2022-03-14"
+}
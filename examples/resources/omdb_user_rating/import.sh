@@ -0,0 +1,2 @@
+# User ratings are imported by film ID and reviewer, separated by "/"
+terraform import omdb_user_rating.example "1a2b3c4d5e6f7a8b/Sam Lowry"
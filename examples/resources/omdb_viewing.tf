@@ -0,0 +1,17 @@
+resource "omdb_film" "brazil" {
+  title = "Brazil"
+  year  = "1985"
+}
+
+resource "omdb_viewing" "brazil_march" {
+  film_id   = omdb_film.brazil.id
+  date      = "2022-03-14"
+  location  = "Community hall"
+  attendees = ["Sam", "Jill", "Harry"]
+}
+
+// films not in the library are recorded by IMDb ID
+resource "omdb_viewing" "alien_april" {
+  imdb_id = "tt0078748"
+  date    = "2022-04-11"
+}
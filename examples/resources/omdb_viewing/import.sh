@@ -0,0 +1,3 @@
+# Viewings are imported by their ID, as recorded in viewings/journal.jsonl in
+# the provider's local_dir
+terraform import omdb_viewing.example 1a2b3c4d5e6f7a8b
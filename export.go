@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/chrismarget/terraform-provider-omdb/omdb"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// exporters write a library's films in the format named by the map key
+var exporters = map[string]func(w io.Writer, films []omdb.Film) error{
+	"csv":      exportCsv,
+	"jsonl":    exportJsonLines,
+	"markdown": exportMarkdown,
+	"html":     exportHtml,
+}
+
+// exportCmd writes every film in local_dir to stdout or a file in one of
+// the formats in exporters.
+func exportCmd(args []string) error {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	localDir := flags.String("local-dir", omdb.DefaultLibraryDir(), "the provider's local_dir")
+	format := flags.String("format", "csv", "output format: "+strings.Join(formats, ", "))
+	output := flags.String("output", "-", "file to write, - for stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	exporter, ok := exporters[*format]
+	if !ok {
+		return fmt.Errorf("unsupported format %q, expected one of %s", *format, strings.Join(formats, ", "))
+	}
+
+	// files which aren't films don't stop the export; fsck explains them
+	films, skipped, err := omdb.NewLibrary(*localDir).Films()
+	if err != nil {
+		return err
+	}
+	for _, err := range skipped {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if *output == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		err = exporter(w, films)
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("error creating output file - %w", err)
+	}
+	w := bufio.NewWriter(f)
+	err = exporter(w, films)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ratingSources returns every rating source found among films, sorted
+func ratingSources(films []omdb.Film) []string {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, film := range films {
+		for _, rating := range film.Ratings {
+			if !seen[rating.Source] {
+				seen[rating.Source] = true
+				sources = append(sources, rating.Source)
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// ratingBySource returns a film's rating from source, or ""
+func ratingBySource(film omdb.Film, source string) string {
+	for _, rating := range film.Ratings {
+		if rating.Source == source {
+			return rating.Value
+		}
+	}
+	return ""
+}
+
+// exportCsv writes a header row and one row per film, with a column for
+// each rating source.
+func exportCsv(w io.Writer, films []omdb.Film) error {
+	sources := ratingSources(films)
+
+	cw := csv.NewWriter(w)
+	err := cw.Write(append([]string{"id", "title", "year", "poster"}, sources...))
+	if err != nil {
+		return err
+	}
+	for _, film := range films {
+		record := []string{film.Id, film.Title, film.Year, film.Poster}
+		for _, source := range sources {
+			record = append(record, ratingBySource(film, source))
+		}
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonLinesRating struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+}
+
+type jsonLinesFilm struct {
+	Id      string            `json:"id"`
+	Title   string            `json:"title"`
+	Year    string            `json:"year"`
+	Poster  string            `json:"poster,omitempty"`
+	Ratings []jsonLinesRating `json:"ratings"`
+}
+
+// exportJsonLines writes one JSON object per film per line
+func exportJsonLines(w io.Writer, films []omdb.Film) error {
+	encoder := json.NewEncoder(w)
+	for _, film := range films {
+		line := jsonLinesFilm{
+			Id:      film.Id,
+			Title:   film.Title,
+			Year:    film.Year,
+			Poster:  film.Poster,
+			Ratings: make([]jsonLinesRating, len(film.Ratings)),
+		}
+		for i, rating := range film.Ratings {
+			line.Ratings[i] = jsonLinesRating{Source: rating.Source, Value: rating.Value}
+		}
+		err := encoder.Encode(&line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownEscaper keeps cell contents from breaking a Markdown table
+var markdownEscaper = strings.NewReplacer(`|`, `\|`, "\n", " ", "\r", "")
+
+// exportMarkdown writes a Markdown table with a column for each rating
+// source.
+func exportMarkdown(w io.Writer, films []omdb.Film) error {
+	sources := ratingSources(films)
+
+	header := append([]string{"Title", "Year"}, sources...)
+	header = append(header, "ID")
+	rows := [][]string{header, make([]string, len(header))}
+	for i := range rows[1] {
+		rows[1][i] = "---"
+	}
+	for _, film := range films {
+		row := []string{film.Title, film.Year}
+		for _, source := range sources {
+			row = append(row, ratingBySource(film, source))
+		}
+		rows = append(rows, append(row, "`"+film.Id+"`"))
+	}
+
+	for i, row := range rows {
+		if i > 1 {
+			for j := range row {
+				row[j] = markdownEscaper.Replace(row[j])
+			}
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var htmlCatalogue = template.Must(template.New("catalogue").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Film catalogue</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.films { display: grid; grid-template-columns: repeat(auto-fill, minmax(12em, 1fr)); gap: 1.5em; }
+.film img { width: 100%; }
+.film h2 { font-size: 1.1em; margin: 0.5em 0 0.2em; }
+.film ul { list-style: none; padding: 0; margin: 0; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Film catalogue</h1>
+<p>{{ len . }} films</p>
+<div class="films">
+{{- range . }}
+<div class="film" id="{{ .Id }}">
+{{- if .Poster }}
+<img src="{{ .Poster }}" alt="{{ .Title }} poster" loading="lazy">
+{{- end }}
+<h2>{{ .Title }}{{ if .Year }} ({{ .Year }}){{ end }}</h2>
+{{- if .Ratings }}
+<ul>
+{{- range .Ratings }}
+<li>{{ .Source }}: {{ .Value }}</li>
+{{- end }}
+</ul>
+{{- end }}
+</div>
+{{- end }}
+</div>
+</body>
+</html>
+`))
+
+// exportHtml writes a static HTML catalogue, with posters where the film
+// file names one.
+func exportHtml(w io.Writer, films []omdb.Film) error {
+	for i := range films {
+		if strings.EqualFold(strings.TrimSpace(films[i].Poster), "N/A") {
+			films[i].Poster = ""
+		}
+	}
+	return htmlCatalogue.Execute(w, films)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/chrismarget/terraform-provider-omdb/omdb"
+	"strings"
+	"testing"
+)
+
+var testFilms = []omdb.Film{
+	{
+		Id:      "aa11",
+		Title:   "Brazil",
+		Year:    "1985",
+		Poster:  "https://example.com/brazil.jpg",
+		Ratings: []omdb.Rating{{Source: "Internet Movie Database", Value: "7.9/10"}},
+	},
+	{
+		Id:      "bb22",
+		Title:   "Pipe | Dream",
+		Year:    "2001",
+		Poster:  "N/A",
+		Ratings: []omdb.Rating{{Source: "Metacritic", Value: "60/100"}},
+	},
+}
+
+func TestExporters(t *testing.T) {
+	for format, exporter := range exporters {
+		t.Run(format, func(t *testing.T) {
+			films := make([]omdb.Film, len(testFilms))
+			copy(films, testFilms)
+
+			var buf bytes.Buffer
+			err := exporter(&buf, films)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+
+			for _, film := range testFilms {
+				if !strings.Contains(out, film.Id) {
+					t.Fatalf("output lacks film %q:\n%s", film.Id, out)
+				}
+			}
+
+			switch format {
+			case "csv":
+				if !strings.HasPrefix(out, "id,title,year,poster,Internet Movie Database,Metacritic\n") {
+					t.Fatalf("unexpected CSV header:\n%s", out)
+				}
+			case "jsonl":
+				lines := strings.Split(strings.TrimSpace(out), "\n")
+				if len(lines) != len(testFilms) {
+					t.Fatalf("expected %d lines, got %d", len(testFilms), len(lines))
+				}
+				for _, line := range lines {
+					if !json.Valid([]byte(line)) {
+						t.Fatalf("invalid JSON line %q", line)
+					}
+				}
+			case "markdown":
+				if !strings.Contains(out, `Pipe \| Dream`) {
+					t.Fatalf("expected | to be escaped:\n%s", out)
+				}
+			case "html":
+				if !strings.Contains(out, `<img src="https://example.com/brazil.jpg"`) {
+					t.Fatalf("expected a poster:\n%s", out)
+				}
+				if strings.Contains(out, `src="N/A"`) {
+					t.Fatalf("expected no poster for N/A:\n%s", out)
+				}
+			}
+		})
+	}
+}
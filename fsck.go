@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/chrismarget/terraform-provider-omdb/omdb"
+)
+
+// fsckCmd checks every file in local_dir and reports problems, optionally
+// repairing those which can be fixed by rewriting the file. It fails when
+// problems remain.
+func fsckCmd(args []string) error {
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	localDir := flags.String("local-dir", omdb.DefaultLibraryDir(), "the provider's local_dir")
+	repair := flags.Bool("repair", false, "rewrite files whose only problem is their formatting")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	library := omdb.NewLibrary(*localDir)
+	problems, err := library.Check()
+	if err != nil {
+		return err
+	}
+
+	if *repair {
+		repaired, err := library.Repair(problems)
+		for _, id := range repaired {
+			fmt.Printf("%s: repaired\n", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		// what's left is what Repair couldn't fix
+		problems, err = library.Check()
+		if err != nil {
+			return err
+		}
+	}
+
+	repairable := 0
+	for _, problem := range problems {
+		note := ""
+		if problem.Repairable {
+			repairable++
+			note = " (repairable)"
+		}
+		fmt.Printf("%s%s\n", problem, note)
+	}
+
+	if len(problems) > 0 {
+		if repairable > 0 {
+			return fmt.Errorf("%d problems found in %q, %d repairable with -repair", len(problems), library.Dir(), repairable)
+		}
+		return fmt.Errorf("%d problems found in %q", len(problems), library.Dir())
+	}
+
+	fmt.Printf("%s: no problems found\n", library.Dir())
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/chrismarget/terraform-provider-omdb/omdb"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// generateConfigCmd writes an omdb_film resource block and a matching import
+// block for every film in local_dir, so that an existing library can be
+// brought under Terraform management with a single apply. Import blocks
+// need Terraform 1.5 or later.
+func generateConfigCmd(args []string) error {
+	flags := flag.NewFlagSet("generate-config", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage of generate-config:")
+		fmt.Fprintln(flags.Output(), "  writes an omdb_film resource and import block for every film in local_dir;")
+		fmt.Fprintln(flags.Output(), "  import blocks need Terraform 1.5 or later")
+		flags.PrintDefaults()
+	}
+	localDir := flags.String("local-dir", omdb.DefaultLibraryDir(), "the provider's local_dir")
+	output := flags.String("output", "-", "file to write, - for stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	library := omdb.NewLibrary(*localDir)
+	ids, err := library.Ids()
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		err = generateConfig(w, library, ids)
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("error creating output file - %w", err)
+	}
+	w := bufio.NewWriter(f)
+	err = generateConfig(w, library, ids)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// generateConfig writes configuration for the films with the given IDs.
+// Films which can't be read are skipped with a comment saying why.
+func generateConfig(w io.Writer, library *omdb.Library, ids []string) error {
+	var blocks []string
+	labels := make(map[string]bool)
+
+	for _, id := range ids {
+		film, err := library.Read(id)
+		if err != nil {
+			blocks = append(blocks, fmt.Sprintf("# skipped %s - %s\n", id, strings.ReplaceAll(err.Error(), "\n", " ")))
+			continue
+		}
+
+		label := resourceLabel(film, labels)
+		labels[label] = true
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "resource \"omdb_film\" %s {\n", hclString(label))
+		attributes := [][2]string{
+			{"title", hclString(film.Title)},
+			{"year", hclString(film.Year)},
+		}
+		if len(film.Tags) > 0 {
+			tags := make([]string, len(film.Tags))
+			for i, tag := range film.Tags {
+				tags[i] = hclString(tag)
+			}
+			attributes = append(attributes, [2]string{"tags", "[" + strings.Join(tags, ", ") + "]"})
+		}
+		if len(film.Labels) > 0 {
+			keys := make([]string, 0, len(film.Labels))
+			for k := range film.Labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			labelPairs := make([]string, len(keys))
+			for i, k := range keys {
+				labelPairs[i] = hclString(k) + " = " + hclString(film.Labels[k])
+			}
+			attributes = append(attributes, [2]string{"labels", "{ " + strings.Join(labelPairs, ", ") + " }"})
+		}
+
+		// align the = signs as terraform fmt would
+		width := 0
+		for _, attribute := range attributes {
+			if len(attribute[0]) > width {
+				width = len(attribute[0])
+			}
+		}
+		if len(film.Ratings) > 0 && len("ratings2") > width {
+			width = len("ratings2")
+		}
+		for _, attribute := range attributes {
+			fmt.Fprintf(&b, "  %-*s = %s\n", width, attribute[0], attribute[1])
+		}
+		if len(film.Ratings) > 0 {
+			b.WriteString("  ratings2 = [\n")
+			for _, rating := range film.Ratings {
+				fmt.Fprintf(&b, "    { source = %s, value = %s },\n", hclString(rating.Source), hclString(rating.Value))
+			}
+			b.WriteString("  ]\n")
+		}
+		b.WriteString("}\n\n")
+		b.WriteString("import {\n")
+		fmt.Fprintf(&b, "  to = omdb_film.%s\n", label)
+		fmt.Fprintf(&b, "  id = %s\n", hclString(film.Id))
+		b.WriteString("}\n")
+
+		blocks = append(blocks, b.String())
+	}
+
+	_, err := io.WriteString(w, strings.Join(blocks, "\n"))
+	return err
+}
+
+// resourceLabel derives a unique resource name such as "brazil_1985" from a
+// film's title and year
+func resourceLabel(film *omdb.Film, taken map[string]bool) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(film.Title + " " + film.Year) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+
+	label := strings.TrimSuffix(b.String(), "_")
+	if label == "" || !unicode.IsLetter(rune(label[0])) {
+		label = "film_" + label
+		label = strings.TrimSuffix(label, "_")
+	}
+
+	unique := label
+	for i := 2; taken[unique]; i++ {
+		unique = label + "_" + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// hclString renders s as a quoted HCL string literal, escaping template
+// sequences so that the value is taken literally
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
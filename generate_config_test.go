@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"github.com/chrismarget/terraform-provider-omdb/omdb"
+	"strings"
+	"testing"
+)
+
+func TestHclString(t *testing.T) {
+	for in, expected := range map[string]string{
+		`Brazil`:            `"Brazil"`,
+		`say "hi"`:          `"say \"hi\""`,
+		`back\slash`:        `"back\\slash"`,
+		"two\nlines":        `"two\nlines"`,
+		`${not} %{a} $ % {`: `"$${not} %%{a} $ % {"`,
+		"bell\a":            `"bell\u0007"`,
+	} {
+		if actual := hclString(in); actual != expected {
+			t.Fatalf("hclString(%q): expected %s, got %s", in, expected, actual)
+		}
+	}
+}
+
+func TestResourceLabel(t *testing.T) {
+	taken := make(map[string]bool)
+	for _, tc := range []struct {
+		title, year, expected string
+	}{
+		{"Brazil", "1985", "brazil_1985"},
+		{"Brazil", "1985", "brazil_1985_2"},
+		{"2001: A Space Odyssey", "1968", "film_2001_a_space_odyssey_1968"},
+		{"Amélie", "2001", "am_lie_2001"},
+		{"", "", "film"},
+	} {
+		actual := resourceLabel(&omdb.Film{Title: tc.title, Year: tc.year}, taken)
+		if actual != tc.expected {
+			t.Fatalf("resourceLabel(%q, %q): expected %q, got %q", tc.title, tc.year, tc.expected, actual)
+		}
+		taken[actual] = true
+	}
+}
+
+func TestGenerateConfig(t *testing.T) {
+	library := omdb.NewLibrary(t.TempDir())
+	film := &omdb.Film{
+		Title:   "Brazil",
+		Year:    "1985",
+		Ratings: []omdb.Rating{{Source: "Metacritic", Value: "84/100"}},
+		Tags:    []string{"dystopia", "satire"},
+		Labels:  map[string]string{"festival": "2026", "mood": "bleak"},
+	}
+	err := library.Create(film)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = generateConfig(&buf, library, []string{film.Id, "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, expected := range []string{
+		`resource "omdb_film" "brazil_1985" {`,
+		`  tags     = ["dystopia", "satire"]`,
+		`  labels   = { "festival" = "2026", "mood" = "bleak" }`,
+		`  ratings2 = [`,
+		`    { source = "Metacritic", value = "84/100" },`,
+		`  to = omdb_film.brazil_1985`,
+		`  id = "` + film.Id + `"`,
+		`# skipped missing - `,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected output to contain %q:\n%s", expected, out)
+		}
+	}
+}
@@ -2,14 +2,26 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"github.com/chrismarget/terraform-provider-omdb/omdb"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"log"
+	"os"
 )
 
 var commit, version string // populated by goreleaser
 
+// subcommands run instead of the provider server when named by the first
+// command line argument
+var subcommands = map[string]func(args []string) error{
+	"export":          exportCmd,
+	"fsck":            fsckCmd,
+	"generate-config": generateConfigCmd,
+	"mock-server":     mockServerCmd,
+}
+
 // NewOmdbProvider instantiates the provider in main
 func NewOmdbProvider() provider.Provider {
 	return &omdb.Provider{
@@ -18,9 +30,43 @@ func NewOmdbProvider() provider.Provider {
 	}
 }
 
+// versionString describes the build: the version and commit injected by
+// goreleaser, or "dev" for a plain go build
+func versionString() string {
+	v := version
+	if v == "" {
+		v = "dev"
+	}
+	if commit != "" {
+		v += " (" + commit + ")"
+	}
+	return "terraform-provider-omdb " + v
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			err := subcommand(os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	var debug, printVersion bool
+	flag.BoolVar(&debug, "debug", false, "run the provider with support for debuggers like delve, printing the TF_REATTACH_PROVIDERS value for terraform")
+	flag.BoolVar(&printVersion, "version", false, "print the provider version and exit")
+	flag.Parse()
+
+	if printVersion {
+		fmt.Println(versionString())
+		return
+	}
+
 	err := providerserver.Serve(context.Background(), NewOmdbProvider, providerserver.ServeOpts{
 		Address: "github.com/chrismarget/omdb",
+		Debug:   debug,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/chrismarget/terraform-provider-omdb/omdbmock"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// stringListFlag collects the values of a repeatable command line flag
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// mockServerCmd runs an OMDb compatible server backed by a fixture directory
+// (see package omdbmock) until interrupted.
+func mockServerCmd(args []string) error {
+	var apiKeys stringListFlag
+	flags := flag.NewFlagSet("mock-server", flag.ContinueOnError)
+	fixtureDir := flags.String("fixtures", "", "directory of <imdbID>.json fixture files and optional "+omdbmock.FaultsFileName+" (required)")
+	listen := flags.String("listen", "127.0.0.1:8080", "address to listen on: host:port or unix:///path/to/socket")
+	latency := flags.Duration("latency", 0, "delay every response by this long")
+	status := flags.Int("status", 0, "respond to every request with this HTTP status code")
+	errorMessage := flags.String("error", "", "respond to every request with this OMDb error message")
+	flags.Var(&apiKeys, "api-key", "API key to accept, may be repeated; any key is accepted when none are given")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *fixtureDir == "" {
+		flags.Usage()
+		return errors.New("-fixtures is required")
+	}
+
+	opts := omdbmock.Options{
+		FixtureDir: *fixtureDir,
+		ApiKeys:    apiKeys,
+	}
+	if *latency > 0 || *status != 0 || *errorMessage != "" {
+		opts.Faults = append(opts.Faults, omdbmock.Fault{
+			LatencyMs: latency.Milliseconds(),
+			Status:    *status,
+			Error:     *errorMessage,
+		})
+	}
+
+	handler, err := omdbmock.New(opts)
+	if err != nil {
+		return err
+	}
+
+	var listener net.Listener
+	if strings.HasPrefix(*listen, "unix://") {
+		socketPath := strings.TrimPrefix(*listen, "unix://")
+		_ = os.Remove(socketPath) // left over from a previous run
+		listener, err = net.Listen("unix", socketPath)
+	} else {
+		listener, err = net.Listen("tcp", *listen)
+	}
+	if err != nil {
+		return fmt.Errorf("error listening on %q - %w", *listen, err)
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	log.Printf("mock OMDb server serving fixtures from %q on %s", *fixtureDir, *listen)
+	err = server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
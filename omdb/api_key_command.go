@@ -0,0 +1,119 @@
+package omdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiKeyCommandTimeout limits how long an api_key_command may run
+var apiKeyCommandTimeout = 30 * time.Second
+
+// maxApiKeyCommandStderr limits how much of a failed api_key_command's
+// stderr is included in the error
+const maxApiKeyCommandStderr = 200
+
+// apiKeyCommandCache holds API keys produced by api_key_command, keyed by
+// the command's argv, so that each credential helper runs at most once per
+// provider process no matter how many times Configure() is invoked.
+var apiKeyCommandCache = struct {
+	sync.Mutex
+	results map[string]*apiKeyCommandResult
+}{results: make(map[string]*apiKeyCommandResult)}
+
+// apiKeyCommandResult is the cached API key produced by one command. Its
+// lock is held while the command runs, so that different commands don't
+// wait for each other.
+type apiKeyCommandResult struct {
+	sync.Mutex
+	key string // empty until the command has succeeded
+}
+
+// runApiKeyCommand executes argv directly (no shell is involved) and returns
+// the API key it writes to stdout. Successful results are cached for the
+// life of the provider process.
+func runApiKeyCommand(ctx context.Context, argv []string) (string, error) {
+	if len(argv) == 0 || argv[0] == "" {
+		return "", errors.New("command must not be empty")
+	}
+
+	cacheKey := strings.Join(argv, "\x00")
+
+	apiKeyCommandCache.Lock()
+	result, ok := apiKeyCommandCache.results[cacheKey]
+	if !ok {
+		result = &apiKeyCommandResult{}
+		apiKeyCommandCache.results[cacheKey] = result
+	}
+	apiKeyCommandCache.Unlock()
+
+	result.Lock()
+	defer result.Unlock()
+
+	if result.key != "" {
+		return result.key, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiKeyCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// a wrapper script's children hold on to its output, so they must be
+	// killed along with it for Wait() to return
+	startProcessGroup(cmd)
+
+	err := cmd.Start()
+	if err != nil {
+		return "", fmt.Errorf("command %q failed - %w", argv[0], err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("command %q did not finish within %s", argv[0], apiKeyCommandTimeout)
+		}
+		return "", fmt.Errorf("command %q failed - %w", argv[0], ctx.Err())
+	}
+	if err != nil {
+		if msg := apiKeyCommandStderr(stderr.String(), stdout.String()); msg != "" {
+			return "", fmt.Errorf("command %q failed - %w: %s", argv[0], err, msg)
+		}
+		return "", fmt.Errorf("command %q failed - %w", argv[0], err)
+	}
+
+	key := strings.TrimSpace(stdout.String())
+	if key == "" {
+		return "", fmt.Errorf("command %q produced no output", argv[0])
+	}
+
+	result.key = key
+	return key, nil
+}
+
+// apiKeyCommandStderr returns what a failed command wrote to stderr, cut
+// short and with anything it wrote to stdout (perhaps a key) masked.
+func apiKeyCommandStderr(stderr string, stdout string) string {
+	redact := newRedactor()
+	for _, line := range strings.Split(stdout, "\n") {
+		redact.add(strings.TrimSpace(line))
+	}
+
+	msg := redact.String(strings.TrimSpace(stderr))
+	if len(msg) > maxApiKeyCommandStderr {
+		msg = strings.ToValidUTF8(msg[:maxApiKeyCommandStderr], "") + "..."
+	}
+	return msg
+}
@@ -0,0 +1,14 @@
+package omdb
+
+import (
+	"os/exec"
+)
+
+// startProcessGroup does nothing on Windows
+func startProcessGroup(*exec.Cmd) {}
+
+// killProcessGroup kills only cmd on Windows: processes it started keep
+// running, and may keep its output open until they finish.
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
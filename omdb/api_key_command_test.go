@@ -0,0 +1,75 @@
+package omdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunApiKeyCommand(t *testing.T) {
+	runs := filepath.Join(t.TempDir(), "runs")
+	argv := []string{"/bin/sh", "-c", `echo run >> "$0"; echo "  key  "`, runs}
+
+	for i := 0; i < 2; i++ {
+		key, err := runApiKeyCommand(context.Background(), argv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "key" {
+			t.Fatalf("expected key %q, got %q", "key", key)
+		}
+	}
+
+	// the second call is answered from the cache
+	data, err := os.ReadFile(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "run") != 1 {
+		t.Fatalf("expected the command to run once, it ran %d times", strings.Count(string(data), "run"))
+	}
+}
+
+func TestRunApiKeyCommandFailure(t *testing.T) {
+	argv := []string{"/bin/sh", "-c", `echo secret-key; echo "lookup of secret-key failed: $0" >&2; exit 1`, strings.Repeat("x", 500)}
+
+	_, err := runApiKeyCommand(context.Background(), argv)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Fatalf("expected stdout to be masked in the error, got %q", err)
+	}
+	if !strings.Contains(err.Error(), "lookup of "+redactedPlaceholder+" failed") {
+		t.Fatalf("expected the error to include stderr, got %q", err)
+	}
+	if strings.Contains(err.Error(), strings.Repeat("x", maxApiKeyCommandStderr)) {
+		t.Fatalf("expected stderr to be cut short, got %q", err)
+	}
+}
+
+func TestRunApiKeyCommandTimeout(t *testing.T) {
+	timeout := apiKeyCommandTimeout
+	apiKeyCommandTimeout = 100 * time.Millisecond
+	defer func() { apiKeyCommandTimeout = timeout }()
+
+	for name, argv := range map[string][]string{
+		"direct": {"sleep", "5"},
+		// the shell forks sleep, which holds the shell's output open
+		"forked": {"/bin/sh", "-c", "sleep 5; echo key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			_, err := runApiKeyCommand(context.Background(), argv)
+			if err == nil || !strings.Contains(err.Error(), "did not finish") {
+				t.Fatalf("expected a timeout error, got %v", err)
+			}
+			if time.Since(start) > 4*time.Second {
+				t.Fatalf("expected the command to be stopped after %s, it took %s", apiKeyCommandTimeout, time.Since(start))
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package omdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// envCassetteMode enables recording OMDb API traffic to, or replaying
+	// it from, the cassette directory
+	envCassetteMode = "OMDB_CASSETTE_MODE"
+
+	// envCassetteDir names the cassette directory
+	envCassetteDir = "OMDB_CASSETTE_DIR"
+
+	cassetteModeRecord = "record"
+	cassetteModeReplay = "replay"
+)
+
+var errCassetteMiss = errors.New("no recorded response")
+
+// cassetteEntry defines what we write to (and expect to find in) each
+// cassette file: one request and its response. API keys are never written.
+type cassetteEntry struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method string `json:"method"`
+	Url    string `json:"url"` // path and sanitized query, no scheme or host
+}
+
+type cassetteResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// cassetteTransport records requests and responses to dir, or replays them
+// from dir without touching the network. Entries are matched on method,
+// path and query with the API key removed, so a cassette recorded with one
+// key (or against one api_url) replays with any other.
+type cassetteTransport struct {
+	mode   string // cassetteModeRecord or cassetteModeReplay
+	dir    string
+	redact *redactor
+	base   http.RoundTripper // unused when replaying
+}
+
+// newCassetteTransport wraps base according to the OMDB_CASSETTE_MODE and
+// OMDB_CASSETTE_DIR environment variables. base is returned unchanged when
+// no cassette mode is set.
+func newCassetteTransport(base http.RoundTripper, redact *redactor) (http.RoundTripper, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv(envCassetteMode)))
+	if mode == "" {
+		return base, nil
+	}
+
+	if mode != cassetteModeRecord && mode != cassetteModeReplay {
+		return nil, fmt.Errorf("%s must be %q or %q, got %q", envCassetteMode, cassetteModeRecord, cassetteModeReplay, mode)
+	}
+
+	dir := os.Getenv(envCassetteDir)
+	if dir == "" {
+		return nil, fmt.Errorf("%s must be set when %s is %q", envCassetteDir, envCassetteMode, mode)
+	}
+
+	if mode == cassetteModeRecord {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("error creating cassette directory - %w", err)
+		}
+	}
+
+	return &cassetteTransport{
+		mode:   mode,
+		dir:    dir,
+		redact: redact,
+		base:   base,
+	}, nil
+}
+
+// sanitizedRequestUrl renders the request's path and query, without the API
+// key, in a canonical form
+func sanitizedRequestUrl(req *http.Request) string {
+	query := req.URL.Query()
+	query.Del("apikey")
+	return (&url.URL{Path: req.URL.Path, RawQuery: query.Encode()}).String()
+}
+
+// fileName returns the cassette file which holds the entry for a request
+func (t *cassetteTransport) fileName(request cassetteRequest) string {
+	sum := sha256.Sum256([]byte(request.Method + " " + request.Url))
+	return filepath.Join(t.dir, fmt.Sprintf("%x.json", sum[:8]))
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request := cassetteRequest{
+		Method: req.Method,
+		Url:    sanitizedRequestUrl(req),
+	}
+
+	if t.mode == cassetteModeReplay {
+		return t.replay(req, request)
+	}
+	return t.record(req, request)
+}
+
+func (t *cassetteTransport) replay(req *http.Request, request cassetteRequest) (*http.Response, error) {
+	fileName := t.fileName(request)
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w for %s %s in cassette directory %q", errCassetteMiss, request.Method, request.Url, t.dir)
+		}
+		return nil, fmt.Errorf("error reading cassette file - %w", err)
+	}
+
+	var entry cassetteEntry
+	err = json.Unmarshal(b, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cassette file %q - %w", fileName, err)
+	}
+
+	if entry.Request != request {
+		return nil, fmt.Errorf("%w for %s %s in cassette directory %q (%q holds %s %s)",
+			errCassetteMiss, request.Method, request.Url, t.dir, fileName, entry.Request.Method, entry.Request.Url)
+	}
+
+	header := make(http.Header)
+	if entry.Response.ContentType != "" {
+		header.Set("Content-Type", entry.Response.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.Status, http.StatusText(entry.Response.Status)),
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(entry.Response.Body)),
+		ContentLength: int64(len(entry.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *cassetteTransport) record(req *http.Request, request cassetteRequest) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := cassetteEntry{
+		Request: request,
+		Response: cassetteResponse{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        t.redact.String(string(body)),
+		},
+	}
+
+	err = t.write(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (t *cassetteTransport) write(entry cassetteEntry) error {
+	// written then renamed, so that a half written file is never replayed
+	err := writeJsonFile(t.fileName(entry.Request), &entry)
+	if err != nil {
+		return fmt.Errorf("error writing cassette file - %w", err)
+	}
+
+	return nil
+}
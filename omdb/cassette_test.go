@@ -0,0 +1,82 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(envCassetteDir, dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// a careless server might echo the key back; it must not be recorded
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"` + r.URL.Query().Get("i") + `","Title":"` + r.URL.Query().Get("apikey") + `","Year":"1980"}`))
+	}))
+
+	// record
+	t.Setenv(envCassetteMode, cassetteModeRecord)
+	transport, err := newCassetteTransport(http.DefaultTransport, newRedactor(testSecretApiKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(server.URL, []string{testSecretApiKey})
+	c.httpClient = &http.Client{Transport: transport}
+
+	recorded, err := c.filmById(context.Background(), "tt0080455")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 cassette file, got %v", files)
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoSecret(t, string(b))
+
+	// replay, with a different key and with the server gone
+	t.Setenv(envCassetteMode, cassetteModeReplay)
+	transport, err = newCassetteTransport(http.DefaultTransport, newRedactor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = newClient(server.URL, []string{"some other key"})
+	c.httpClient = &http.Client{Transport: transport}
+
+	replayed, err := c.filmById(context.Background(), "tt0080455")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.ImdbID != recorded.ImdbID || replayed.Year != recorded.Year {
+		t.Fatalf("replayed %+v differs from recorded %+v", replayed, recorded)
+	}
+
+	_, err = c.filmById(context.Background(), "tt0088247")
+	if !errors.Is(err, errCassetteMiss) {
+		t.Fatalf("expected errCassetteMiss for an unrecorded request, got %v", err)
+	}
+}
+
+func TestCassetteModeRequiresDir(t *testing.T) {
+	t.Setenv(envCassetteMode, cassetteModeReplay)
+	t.Setenv(envCassetteDir, "")
+
+	_, err := newCassetteTransport(http.DefaultTransport, newRedactor())
+	if err == nil {
+		t.Fatal("expected an error when the cassette directory is not set")
+	}
+}
@@ -0,0 +1,343 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxConcurrentRequests limits the number of simultaneous requests a
+	// single data source will make against the OMDb API
+	maxConcurrentRequests = 8
+
+	// probeImdbId is looked up to check that the OMDb API is reachable and
+	// the API key is valid
+	probeImdbId = "tt0000001"
+
+	apiMessageInvalidApiKey = "Invalid API key!"
+	apiMessageRequestLimit  = "Request limit reached!"
+)
+
+var (
+	errInvalidApiKey = errors.New("invalid API key")
+	errRequestLimit  = errors.New("request limit reached")
+)
+
+// client is the OMDb API client shared by all data sources. It gets
+// instantiated in the provider.Provider's Configure() method.
+//
+// Identical requests made during the life of the provider process are
+// coalesced: concurrent callers share a single in-flight request and later
+// callers are answered from memory.
+type client struct {
+	baseUrl    string
+	keys       *keyRing
+	httpClient *http.Client
+	redact     *redactor
+	quota      *quotaTracker // nil disables request counting
+	format     string        // responseFormatJson or responseFormatXml
+
+	inFlight singleflight.Group
+	memoLock sync.Mutex
+	memo     map[string][]byte // response bodies keyed by normalized query
+}
+
+func newClient(baseUrl string, apiKeys []string) *client {
+	return &client{
+		baseUrl:    baseUrl,
+		keys:       newKeyRing(apiKeys),
+		httpClient: http.DefaultClient,
+		format:     responseFormatJson,
+		redact:     newRedactor(apiKeys...),
+		memo:       make(map[string][]byte),
+	}
+}
+
+// apiErrorResponse describes the fields OMDb includes in every response
+// to indicate success or failure
+type apiErrorResponse struct {
+	Response string `json:"Response"`
+	Error    string `json:"Error"`
+}
+
+// apiError is returned when the OMDb API reports a failure. errors.Is()
+// matches errInvalidApiKey and errRequestLimit against the corresponding
+// OMDb error messages.
+type apiError struct {
+	message string
+}
+
+func newApiError(message string) *apiError {
+	if message == "" {
+		message = "OMDb API indicated failure without an error message"
+	}
+	return &apiError{message: message}
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func (e *apiError) Is(target error) bool {
+	switch target {
+	case errInvalidApiKey:
+		return e.message == apiMessageInvalidApiKey
+	case errRequestLimit:
+		return e.message == apiMessageRequestLimit
+	}
+	return false
+}
+
+// normalizeQuery renders query parameters in a canonical form (lowercase
+// keys, trimmed values, sorted by key) suitable for use as a cache key.
+// The API key is never part of the query at this point.
+func normalizeQuery(query url.Values) string {
+	normalized := make(url.Values, len(query))
+	for k, vals := range query {
+		k = strings.ToLower(strings.TrimSpace(k))
+		for _, v := range vals {
+			normalized.Add(k, strings.TrimSpace(v))
+		}
+	}
+	return normalized.Encode()
+}
+
+// get performs an HTTP GET against the OMDb API and decodes the JSON
+// response into out. The API key is added to the query here; callers
+// should not include it. API keys are masked from returned errors and
+// from anything logged along the way.
+func (c *client) get(ctx context.Context, query url.Values, out interface{}) error {
+	ctx = newLogSubsystem(ctx, logSubsystemApi)
+	ctx = c.redact.Context(ctx)
+	if c.format != responseFormatJson {
+		query.Set("r", c.format)
+	}
+	key := normalizeQuery(query)
+
+	body, err := c.fetch(ctx, key)
+	if err != nil {
+		return c.redact.Error(err)
+	}
+
+	err = decodeResponse(c.format, body, out)
+	if err != nil {
+		return c.redact.Error(fmt.Errorf("error decoding API response - %w", err))
+	}
+
+	return nil
+}
+
+// fetch returns the response body for the normalized query, either from
+// the memo or by making (or joining) an HTTP request.
+func (c *client) fetch(ctx context.Context, key string) ([]byte, error) {
+	c.memoLock.Lock()
+	body, ok := c.memo[key]
+	c.memoLock.Unlock()
+	if ok {
+		tflog.SubsystemDebug(ctx, logSubsystemApi, "OMDb request", map[string]interface{}{
+			"method":    http.MethodGet,
+			"url":       c.baseUrl + "/?" + key,
+			"cache_hit": true,
+		})
+		return body, nil
+	}
+
+	for {
+		result, err, shared := c.inFlight.Do(key, func() (interface{}, error) {
+			body, err := c.do(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+
+			// OMDb's "Response":"False" answers (film not found, etc.) may not
+			// be final, so only successful lookups are remembered
+			var apiResponse apiErrorResponse
+			if decodeResponse(c.format, body, &apiResponse) == nil && apiResponse.Response == "True" {
+				c.memoLock.Lock()
+				c.memo[key] = body
+				c.memoLock.Unlock()
+			}
+
+			return body, nil
+		})
+		if shared {
+			tflog.SubsystemDebug(ctx, logSubsystemApi, "OMDb request joined an identical in-flight request", map[string]interface{}{
+				"method":    http.MethodGet,
+				"url":       c.baseUrl + "/?" + key,
+				"cache_hit": true,
+			})
+		}
+		if err != nil {
+			// the request was made with the context of whichever caller
+			// started it; when that caller gave up, the others try again
+			if shared && ctx.Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+				continue
+			}
+			return nil, err
+		}
+
+		return result.([]byte), nil
+	}
+}
+
+// do makes the HTTP request for the normalized query and returns the
+// response body. API keys are used round-robin; a key which OMDb rejects is
+// quarantined and the request is retried with the next key, as it is when a
+// key's daily budget is spent. The quota tracker refuses a spent key until
+// its budget resets, so those keys aren't quarantined.
+func (c *client) do(ctx context.Context, normalized string) ([]byte, error) {
+	query, err := url.ParseQuery(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing query %q - %w", normalized, err)
+	}
+
+	var lastErr error
+	for range c.keys.all() {
+		apiKey, err := c.keys.pick()
+		if err != nil {
+			if lastErr == nil {
+				lastErr = err
+			}
+			break
+		}
+
+		body, err := c.doWithKey(ctx, query, apiKey)
+		switch {
+		case errors.Is(err, errBudgetExhausted):
+			lastErr = err
+			continue
+		case errors.Is(err, errInvalidApiKey) || errors.Is(err, errRequestLimit):
+			tflog.SubsystemWarn(ctx, logSubsystemApi, "quarantining OMDb API key", map[string]interface{}{
+				"key_id":     quotaKeyId(apiKey),
+				"error":      err.Error(),
+				"quarantine": keyQuarantineDuration.String(),
+			})
+			c.keys.quarantine(apiKey, err)
+			lastErr = err
+			continue
+		}
+
+		return body, err
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, errNoApiKeys
+}
+
+// doWithKey makes the HTTP request using a specific API key and returns the
+// response body.
+func (c *client) doWithKey(ctx context.Context, query url.Values, apiKey string) ([]byte, error) {
+	if c.quota != nil {
+		err := c.quota.spend(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	withKey := make(url.Values, len(query)+1)
+	for k, v := range query {
+		withKey[k] = v
+	}
+	withKey.Set("apikey", apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+"/?"+withKey.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating http request - %w", err)
+	}
+
+	logFields := map[string]interface{}{
+		"method":    req.Method,
+		"url":       c.redact.String(req.URL.String()),
+		"cache_hit": false,
+	}
+
+	start := time.Now()
+	httpResponse, err := c.httpClient.Do(req)
+	if err != nil {
+		logFields["latency_ms"] = time.Since(start).Milliseconds()
+		logFields["error"] = c.redact.String(err.Error())
+		tflog.SubsystemWarn(ctx, logSubsystemApi, "OMDb request failed", logFields)
+		return nil, fmt.Errorf("error making http request - %w", err)
+	}
+	defer func() { _ = httpResponse.Body.Close() }()
+
+	body, err := io.ReadAll(httpResponse.Body)
+	logFields["status"] = httpResponse.StatusCode
+	logFields["latency_ms"] = time.Since(start).Milliseconds()
+	if err != nil {
+		logFields["error"] = err.Error()
+		tflog.SubsystemWarn(ctx, logSubsystemApi, "OMDb request failed", logFields)
+		return nil, fmt.Errorf("error reading API response - %w", err)
+	}
+	logFields["bytes"] = len(body)
+	tflog.SubsystemDebug(ctx, logSubsystemApi, "OMDb request", logFields)
+
+	// OMDb explains some failures (bad API key, quota) in the body
+	var errResponse apiErrorResponse
+	if decodeResponse(c.format, body, &errResponse) == nil && errResponse.Response == "False" {
+		err = newApiError(errResponse.Error)
+		if errors.Is(err, errInvalidApiKey) && c.quota != nil {
+			// OMDb doesn't count requests made with a key it rejects
+			refundErr := c.quota.refund(ctx, apiKey)
+			if refundErr != nil {
+				return nil, refundErr
+			}
+		}
+		if httpResponse.StatusCode != http.StatusOK || errors.Is(err, errInvalidApiKey) || errors.Is(err, errRequestLimit) {
+			return nil, err
+		}
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OMDb API returned http status %q", httpResponse.Status)
+	}
+
+	return body, nil
+}
+
+// filmById fetches a single film from the OMDb API using its IMDb ID.
+func (c *client) filmById(ctx context.Context, id string) (*filmByIdApiResponse, error) {
+	var apiResponse filmByIdApiResponse
+	err := c.get(ctx, url.Values{"i": {id}}, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiResponse.Response == "False" {
+		return nil, c.redact.Error(newApiError(apiResponse.Error))
+	}
+	apiResponse.Ratings = normalizeRatings(apiResponse.Ratings)
+
+	return &apiResponse, nil
+}
+
+// probe makes a cheap request to check that the OMDb API is reachable, and
+// that it accepts the API key. Failure to find the probe film is not an
+// error.
+func (c *client) probe(ctx context.Context) error {
+	var apiResponse apiErrorResponse
+	err := c.get(ctx, url.Values{"i": {probeImdbId}}, &apiResponse)
+	if err != nil {
+		return err
+	}
+
+	if apiResponse.Response == "False" {
+		err = newApiError(apiResponse.Error)
+		if errors.Is(err, errInvalidApiKey) || errors.Is(err, errRequestLimit) {
+			return c.redact.Error(err)
+		}
+	}
+
+	return nil
+}
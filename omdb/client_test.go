@@ -0,0 +1,256 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRotatesAndQuarantinesKeys(t *testing.T) {
+	var lock sync.Mutex
+	requestsByKey := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("apikey")
+		lock.Lock()
+		requestsByKey[key]++
+		lock.Unlock()
+
+		switch key {
+		case "invalid":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"Response":"False","Error":"Invalid API key!"}`))
+		case "exhausted":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"Response":"False","Error":"Request limit reached!"}`))
+		default:
+			_, _ = w.Write([]byte(`{"Response":"True","imdbID":"` + r.URL.Query().Get("i") + `","Title":"t","Year":"1980"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"invalid", "exhausted", "good"})
+
+	for _, id := range []string{"tt1", "tt2", "tt3"} {
+		film, err := c.filmById(context.Background(), id)
+		if err != nil {
+			t.Fatalf("filmById(%q): %s", id, err)
+		}
+		if film.ImdbID != id {
+			t.Fatalf("expected film %q, got %q", id, film.ImdbID)
+		}
+	}
+
+	// rejected keys are tried once, then quarantined
+	for key, expected := range map[string]int{"invalid": 1, "exhausted": 1, "good": 3} {
+		if requestsByKey[key] != expected {
+			t.Fatalf("expected %d requests with key %q, got %d", expected, key, requestsByKey[key])
+		}
+	}
+}
+
+func TestClientAllKeysRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"Response":"False","Error":"Request limit reached!"}`))
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"a", "b"})
+
+	_, err := c.filmById(context.Background(), "tt1")
+	if !errors.Is(err, errRequestLimit) {
+		t.Fatalf("expected errRequestLimit, got %v", err)
+	}
+
+	_, err = c.filmById(context.Background(), "tt2")
+	if !errors.Is(err, errNoApiKeys) || !errors.Is(err, errRequestLimit) {
+		t.Fatalf("expected errNoApiKeys wrapping errRequestLimit once every key is quarantined, got %v", err)
+	}
+}
+
+func TestClientBudgetExhausted(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"` + r.URL.Query().Get("i") + `","Title":"t","Year":"1980"}`))
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"key"})
+	c.quota = newQuotaTracker(t.TempDir(), 1)
+
+	_, err := c.filmById(context.Background(), "tt1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every request once the budget is spent explains why
+	for _, id := range []string{"tt2", "tt3"} {
+		_, err = c.filmById(context.Background(), id)
+		if !errors.Is(err, errBudgetExhausted) || !strings.Contains(err.Error(), "the budget resets at") {
+			t.Fatalf("filmById(%q): expected errBudgetExhausted with the reset time, got %v", id, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request to OMDb, got %d", requests)
+	}
+}
+
+func TestClientCoalescesIdenticalRequests(t *testing.T) {
+	var requests int32
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-release
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"tt1","Title":"t","Year":"1980"}`))
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"k"})
+
+	const callers = 5
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := c.filmById(context.Background(), "tt1")
+			errs <- err
+		}()
+	}
+	<-arrived
+	time.Sleep(50 * time.Millisecond) // let the other callers join
+	close(release)
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// and later lookups are answered from memory
+	_, err := c.filmById(context.Background(), " tt1 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected identical lookups to share a request, got %d requests", n)
+	}
+}
+
+func TestClientMemoizesOnlySuccessfulResponses(t *testing.T) {
+	var lock sync.Mutex
+	requestsById := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("i")
+		lock.Lock()
+		requestsById[id]++
+		lock.Unlock()
+
+		if id == "tt404" {
+			_, _ = w.Write([]byte(`{"Response":"False","Error":"Incorrect IMDb ID."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"` + id + `","Title":"t","Year":"1980"}`))
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"k"})
+
+	for i := 0; i < 2; i++ {
+		_, err := c.filmById(context.Background(), "tt1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.filmById(context.Background(), "tt404")
+		if err == nil {
+			t.Fatal("expected an error for an unknown film")
+		}
+	}
+
+	for id, expected := range map[string]int{"tt1": 1, "tt404": 2} {
+		if requestsById[id] != expected {
+			t.Fatalf("expected %d requests for %q, got %d", expected, id, requestsById[id])
+		}
+	}
+}
+
+func TestClientSharedRequestSurvivesCancelledCaller(t *testing.T) {
+	var requests int32
+	arrived := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// the first request hangs until its caller gives up
+			arrived <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"tt1","Title":"t","Year":"1980"}`))
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"k"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelledErr := make(chan error, 1)
+	go func() {
+		_, err := c.filmById(ctx, "tt1")
+		cancelledErr <- err
+	}()
+	<-arrived
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := c.filmById(context.Background(), "tt1")
+		waiterErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond) // let the waiter join
+	cancel()
+
+	if err := <-cancelledErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled caller to fail with context.Canceled, got %v", err)
+	}
+	if err := <-waiterErr; err != nil {
+		t.Fatalf("expected the other caller to succeed, got %v", err)
+	}
+}
+
+func TestClientDoesNotCountRejectedKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") == "invalid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"Response":"False","Error":"Invalid API key!"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"tt1","Title":"t","Year":"1980"}`))
+	}))
+	defer server.Close()
+
+	c := newClient(server.URL, []string{"invalid", "good"})
+	c.quota = newQuotaTracker(t.TempDir(), 0)
+
+	_, err := c.filmById(context.Background(), "tt1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, expected := range map[string]int64{"invalid": 0, "good": 1} {
+		usage, err := c.quota.usage(context.Background(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usage.used != expected {
+			t.Fatalf("expected %d requests counted for key %q, got %d", expected, key, usage.used)
+		}
+	}
+}
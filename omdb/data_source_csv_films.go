@@ -0,0 +1,135 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"os"
+)
+
+// csvFilmsData is a terraform config/plan/state style object
+type csvFilmsData struct {
+	Path   types.String       `tfsdk:"path"`
+	Format types.String       `tfsdk:"format"`
+	Films  []csvFilmsFilmData `tfsdk:"films"`
+}
+
+type csvFilmsFilmData struct {
+	ImdbId      types.String `tfsdk:"imdb_id"`
+	Title       types.String `tfsdk:"title"`
+	Year        types.String `tfsdk:"year"`
+	UserRating  types.Int64  `tfsdk:"user_rating"`
+	WatchedDate types.String `tfsdk:"watched_date"`
+}
+
+var _ datasource.DataSource = &DataSourceCsvFilms{}
+
+// DataSourceCsvFilms implements the datasource.DataSource interface
+type DataSourceCsvFilms struct{}
+
+func (d *DataSourceCsvFilms) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_csv_films"
+}
+
+func (d *DataSourceCsvFilms) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Data Source reads a watch history exported from Letterboxd or IMDb as CSV, " +
+			"detecting which from the file's header row, and returns its films in a common form. Films " +
+			"which appear more than once, such as rewatches in a Letterboxd diary, are returned once, " +
+			"from their most recent entry. Reading it does not make a request.",
+		Attributes: map[string]tfsdk.Attribute{
+			"path": {
+				MarkdownDescription: "Path of the CSV file, e.g. Letterboxd's `diary.csv` or `ratings.csv`, or IMDb's `ratings.csv`",
+				Required:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"format": {
+				MarkdownDescription: "The detected format: `letterboxd` or `imdb`",
+				Computed:            true,
+				Type:                types.StringType,
+			},
+			"films": {
+				MarkdownDescription: "Films, in the order of the file",
+				Computed:            true,
+				Attributes: tfsdk.ListNestedAttributes(map[string]tfsdk.Attribute{
+					"imdb_id": {
+						MarkdownDescription: "IMDb ID, null for Letterboxd exports, which don't include them",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"title": {
+						MarkdownDescription: "Film title",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"year": {
+						MarkdownDescription: "Release year, null when the file doesn't say",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"user_rating": {
+						MarkdownDescription: "Your rating from 1 to 10 (Letterboxd's half stars doubled), null when unrated",
+						Computed:            true,
+						Type:                types.Int64Type,
+					},
+					"watched_date": {
+						MarkdownDescription: "Date watched (Letterboxd's watched or logged date, IMDb's date rated) as " +
+							"YYYY-MM-DD, null when the file doesn't say",
+						Computed: true,
+						Type:     types.StringType,
+					},
+				}),
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (d *DataSourceCsvFilms) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config csvFilmsData
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	f, err := os.Open(config.Path.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "error opening CSV file", err.Error())
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	format, films, err := parseWatchHistory(f)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("path"),
+			fmt.Sprintf("error parsing CSV file %q", config.Path.Value), err.Error())
+		return
+	}
+
+	state := csvFilmsData{
+		Path:   config.Path,
+		Format: types.String{Value: format},
+		Films:  make([]csvFilmsFilmData, len(films)),
+	}
+	for i, film := range films {
+		state.Films[i] = csvFilmsFilmData{
+			ImdbId:      types.String{Value: film.imdbId, Null: film.imdbId == ""},
+			Title:       types.String{Value: film.title},
+			Year:        types.String{Value: film.year, Null: film.year == ""},
+			UserRating:  types.Int64{Value: film.userRating, Null: film.userRating == 0},
+			WatchedDate: types.String{Value: film.watchedDate, Null: film.watchedDate == ""},
+		}
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -2,25 +2,21 @@ package omdb
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"net/http"
 )
 
-// filmByIdApiResponse defines what we expect from urlFilmById
+// filmByIdApiResponse defines what we expect from an OMDb lookup by ID
 type filmByIdApiResponse struct {
-	ImdbID  string `json:"imdbID"`
-	Title   string `json:"Title"`
-	Year    string `json:"Year"`
-	Ratings []struct {
-		Source string `json:"Source"`
-		Value  string `json:"Value"`
-	} `json:"Ratings"`
+	apiErrorResponse
+	ImdbID  string   `json:"imdbID"`
+	Title   string   `json:"Title"`
+	Year    string   `json:"Year"`
+	Ratings []Rating `json:"Ratings"`
 }
 
 // filmByIdData is a terraform config/plan/state style object
@@ -39,11 +35,11 @@ type filmRatingData struct {
 }
 
 var _ datasource.DataSource = &DataSourceFilmById{}
+var _ datasource.DataSourceWithConfigure = &DataSourceFilmById{}
 
 // DataSourceFilmById implements the datasource.DataSourceWithConfigure interface
 type DataSourceFilmById struct {
-	apiBaseUrl string
-	apiKey     string
+	client *client
 }
 
 func (d *DataSourceFilmById) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -60,12 +56,12 @@ func (d *DataSourceFilmById) GetSchema(_ context.Context) (tfsdk.Schema, diag.Di
 				Type:                types.StringType,
 			},
 			"title": {
-				MarkdownDescription: "Film title",
+				MarkdownDescription: "Film title, null when OMDb reports N/A",
 				Computed:            true,
 				Type:                types.StringType,
 			},
 			"year": {
-				MarkdownDescription: "Release year",
+				MarkdownDescription: "Release year, null when OMDb reports N/A",
 				Computed:            true,
 				Type:                types.StringType,
 			},
@@ -123,12 +119,21 @@ func (d *DataSourceFilmById) Configure(ctx context.Context, req datasource.Confi
 	}
 
 	if providerData, ok := req.ProviderData.(*providerDataSourceData); ok {
-		d.apiBaseUrl = providerData.apiBaseUrl
-		d.apiKey = providerData.apiKey
+		d.client = providerData.client
+	} else {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
 	}
 }
 
 func (d *DataSourceFilmById) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Unconfigured OMDb API client",
+			"Expected a configured OMDb API client. Please report this issue to the provider developers.")
+		return
+	}
+	defer func() { resp.Diagnostics = d.client.redact.Diagnostics(resp.Diagnostics) }()
+
 	var config filmByIdData
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
@@ -136,22 +141,16 @@ func (d *DataSourceFilmById) Read(ctx context.Context, req datasource.ReadReques
 		return
 	}
 
-	httpResponse, err := http.Get(fmt.Sprintf(d.apiBaseUrl+urlFilmById, config.ImdbId.Value, d.apiKey))
+	apiResponse, err := d.client.filmById(ctx, config.ImdbId.Value)
 	if err != nil {
-		resp.Diagnostics.AddError("error making http request", err.Error())
+		resp.Diagnostics.AddError("error fetching film from OMDb API", err.Error())
 		return
 	}
 
-	var apiResponse filmByIdApiResponse
-	err = json.NewDecoder(httpResponse.Body).Decode(&apiResponse)
-	if err != nil {
-		resp.Diagnostics.AddError("error decoding API response", err.Error())
-	}
-
 	state := filmByIdData{
 		ImdbId: types.String{Value: config.ImdbId.Value},
-		Title:  types.String{Value: apiResponse.Title},
-		Year:   types.String{Value: apiResponse.Year},
+		Title:  optionalString(apiResponse.Title),
+		Year:   optionalString(apiResponse.Year),
 		//Ratings0: []filmRatingData{},
 		//Ratings1: []types.Object{},
 		//Ratings2: types.List{},
@@ -0,0 +1,197 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"sort"
+	"sync"
+)
+
+// filmsByIdsData is a terraform config/plan/state style object
+type filmsByIdsData struct {
+	ImdbIds     []string                      `tfsdk:"imdb_ids"`
+	FailOnError types.Bool                    `tfsdk:"fail_on_error"`
+	Films       map[string]filmsByIdsFilmData `tfsdk:"films"`
+	Errors      map[string]string             `tfsdk:"errors"`
+}
+
+type filmsByIdsFilmData struct {
+	Title   types.String     `tfsdk:"title"`
+	Year    types.String     `tfsdk:"year"`
+	Ratings []filmRatingData `tfsdk:"ratings"`
+}
+
+var _ datasource.DataSourceWithConfigure = &DataSourceFilmsByIds{}
+
+// DataSourceFilmsByIds implements the datasource.DataSourceWithConfigure interface
+type DataSourceFilmsByIds struct {
+	client *client
+}
+
+func (d *DataSourceFilmsByIds) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_films_by_ids"
+}
+
+func (d *DataSourceFilmsByIds) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Data Source returns details about many films by their IMDb IDs. " +
+			"Films are fetched concurrently, and IDs which cannot be resolved are reported " +
+			"in `errors` rather than failing the read, unless `fail_on_error` is set.",
+		Attributes: map[string]tfsdk.Attribute{
+			"imdb_ids": {
+				MarkdownDescription: "Set of unique IDs used by both OMDb and IMDb",
+				Required:            true,
+				Type:                types.SetType{ElemType: types.StringType},
+				Validators: []tfsdk.AttributeValidator{
+					setvalidator.ValuesAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"fail_on_error": {
+				MarkdownDescription: "When `true`, any ID which cannot be resolved fails the read. Defaults to `false`.",
+				Optional:            true,
+				Type:                types.BoolType,
+			},
+			"films": {
+				MarkdownDescription: "Films which were found, keyed by IMDb ID",
+				Computed:            true,
+				Attributes: tfsdk.MapNestedAttributes(map[string]tfsdk.Attribute{
+					"title": {
+						MarkdownDescription: "Film title, null when OMDb reports N/A",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"year": {
+						MarkdownDescription: "Release year, null when OMDb reports N/A",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"ratings": {
+						MarkdownDescription: "Ratings",
+						Computed:            true,
+						Attributes: tfsdk.ListNestedAttributes(map[string]tfsdk.Attribute{
+							"source": {
+								MarkdownDescription: "Review source",
+								Computed:            true,
+								Type:                types.StringType,
+							},
+							"value": {
+								MarkdownDescription: "Review value",
+								Computed:            true,
+								Type:                types.StringType,
+							},
+						}),
+					},
+				}),
+			},
+			"errors": {
+				MarkdownDescription: "Error messages for IDs which could not be resolved, keyed by IMDb ID",
+				Computed:            true,
+				Type:                types.MapType{ElemType: types.StringType},
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (d *DataSourceFilmsByIds) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerDataSourceData); ok {
+		d.client = providerData.client
+	} else {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (d *DataSourceFilmsByIds) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Unconfigured OMDb API client",
+			"Expected a configured OMDb API client. Please report this issue to the provider developers.")
+		return
+	}
+	defer func() { resp.Diagnostics = d.client.redact.Diagnostics(resp.Diagnostics) }()
+
+	var config filmsByIdsData
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state := filmsByIdsData{
+		ImdbIds:     config.ImdbIds,
+		FailOnError: config.FailOnError,
+		Films:       make(map[string]filmsByIdsFilmData, len(config.ImdbIds)),
+		Errors:      make(map[string]string),
+	}
+
+	// fetch films concurrently, with no more than maxConcurrentRequests
+	// requests in flight at any time.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, maxConcurrentRequests)
+	for _, id := range config.ImdbIds {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				defer mu.Unlock()
+				state.Errors[id] = ctx.Err().Error()
+				return
+			}
+
+			apiResponse, err := d.client.filmById(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				state.Errors[id] = err.Error()
+				return
+			}
+
+			film := filmsByIdsFilmData{
+				Title:   optionalString(apiResponse.Title),
+				Year:    optionalString(apiResponse.Year),
+				Ratings: make([]filmRatingData, len(apiResponse.Ratings)),
+			}
+			for i, rating := range apiResponse.Ratings {
+				film.Ratings[i] = filmRatingData{
+					Source: types.String{Value: rating.Source},
+					Value:  types.String{Value: rating.Value},
+				}
+			}
+			state.Films[id] = film
+		}(id)
+	}
+	wg.Wait()
+
+	if config.FailOnError.Value && len(state.Errors) > 0 {
+		failedIds := make([]string, 0, len(state.Errors))
+		for id := range state.Errors {
+			failedIds = append(failedIds, id)
+		}
+		sort.Strings(failedIds)
+		for _, id := range failedIds {
+			resp.Diagnostics.AddAttributeError(path.Root("imdb_ids"),
+				fmt.Sprintf("error fetching film %q from OMDb API", id), state.Errors[id])
+		}
+		return
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
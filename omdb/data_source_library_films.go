@@ -0,0 +1,147 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// libraryFilmsData is a terraform config/plan/state style object
+type libraryFilmsData struct {
+	Tags   []string                        `tfsdk:"tags"`
+	Labels map[string]string               `tfsdk:"labels"`
+	Ids    []string                        `tfsdk:"ids"`
+	Films  map[string]libraryFilmsFilmData `tfsdk:"films"`
+}
+
+type libraryFilmsFilmData struct {
+	Title  types.String      `tfsdk:"title"`
+	Year   types.String      `tfsdk:"year"`
+	Tags   []string          `tfsdk:"tags"`
+	Labels map[string]string `tfsdk:"labels"`
+}
+
+var _ datasource.DataSourceWithConfigure = &DataSourceLibraryFilms{}
+
+// DataSourceLibraryFilms implements the datasource.DataSourceWithConfigure interface
+type DataSourceLibraryFilms struct {
+	library *Library
+}
+
+func (d *DataSourceLibraryFilms) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_library_films"
+}
+
+func (d *DataSourceLibraryFilms) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Data Source lists the films in `local_dir`, optionally only those with " +
+			"given tags and labels. Reading it does not make a request. Files which can't be read as films " +
+			"are skipped with a warning.",
+		Attributes: map[string]tfsdk.Attribute{
+			"tags": {
+				MarkdownDescription: "When set, only films with all of these tags are listed",
+				Optional:            true,
+				Type:                types.SetType{ElemType: types.StringType},
+			},
+			"labels": {
+				MarkdownDescription: "When set, only films with all of these labels, with the same values, are listed",
+				Optional:            true,
+				Type:                types.MapType{ElemType: types.StringType},
+			},
+			"ids": {
+				MarkdownDescription: "IDs of the films listed, ordered by title and year",
+				Computed:            true,
+				Type:                types.ListType{ElemType: types.StringType},
+			},
+			"films": {
+				MarkdownDescription: "Films listed, keyed by ID",
+				Computed:            true,
+				Attributes: tfsdk.MapNestedAttributes(map[string]tfsdk.Attribute{
+					"title": {
+						MarkdownDescription: "Film title",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"year": {
+						MarkdownDescription: "Release year",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"tags": {
+						MarkdownDescription: "The film's tags",
+						Computed:            true,
+						Type:                types.SetType{ElemType: types.StringType},
+					},
+					"labels": {
+						MarkdownDescription: "The film's labels",
+						Computed:            true,
+						Type:                types.MapType{ElemType: types.StringType},
+					},
+				}),
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (d *DataSourceLibraryFilms) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerDataSourceData); ok {
+		d.library = NewLibrary(providerData.localDir)
+	} else {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (d *DataSourceLibraryFilms) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.library == nil {
+		resp.Diagnostics.AddError("Unconfigured film library",
+			"Expected a configured film library. Please report this issue to the provider developers.")
+		return
+	}
+	var config libraryFilmsData
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	films, skipped, err := d.library.Films()
+	if err != nil {
+		resp.Diagnostics.AddError("error reading film library", err.Error())
+		return
+	}
+	for _, err := range skipped {
+		resp.Diagnostics.AddWarning("film file skipped", err.Error())
+	}
+
+	state := libraryFilmsData{
+		Tags:   config.Tags,
+		Labels: config.Labels,
+		Ids:    []string{},
+		Films:  make(map[string]libraryFilmsFilmData),
+	}
+	for _, film := range films {
+		if !film.HasTags(config.Tags) || !film.HasLabels(config.Labels) {
+			continue
+		}
+
+		state.Ids = append(state.Ids, film.Id)
+		state.Films[film.Id] = libraryFilmsFilmData{
+			Title:  types.String{Value: film.Title},
+			Year:   types.String{Value: film.Year},
+			Tags:   film.Tags,
+			Labels: film.Labels,
+		}
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
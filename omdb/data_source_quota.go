@@ -0,0 +1,113 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"time"
+)
+
+// quotaData is a terraform config/plan/state style object
+type quotaData struct {
+	Used      types.Int64  `tfsdk:"used"`
+	Budget    types.Int64  `tfsdk:"budget"`
+	Remaining types.Int64  `tfsdk:"remaining"`
+	ResetsAt  types.String `tfsdk:"resets_at"`
+}
+
+var _ datasource.DataSourceWithConfigure = &DataSourceQuota{}
+
+// DataSourceQuota implements the datasource.DataSourceWithConfigure interface
+type DataSourceQuota struct {
+	client *client
+}
+
+func (d *DataSourceQuota) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_quota"
+}
+
+func (d *DataSourceQuota) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Data Source reports how many OMDb API requests have been made with the " +
+			"provider's API key during the current UTC day. When the provider is configured with several " +
+			"`api_keys`, figures are totals across all of them. Reading it does not make a request.",
+		Attributes: map[string]tfsdk.Attribute{
+			"used": {
+				MarkdownDescription: "Requests made today",
+				Computed:            true,
+				Type:                types.Int64Type,
+			},
+			"budget": {
+				MarkdownDescription: "The provider's `daily_request_budget` (multiplied by the number of API keys), null when not set",
+				Computed:            true,
+				Type:                types.Int64Type,
+			},
+			"remaining": {
+				MarkdownDescription: "Requests left in today's budget, null when no budget is set",
+				Computed:            true,
+				Type:                types.Int64Type,
+			},
+			"resets_at": {
+				MarkdownDescription: "RFC3339 timestamp of the next UTC midnight, when the count resets",
+				Computed:            true,
+				Type:                types.StringType,
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (d *DataSourceQuota) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerDataSourceData); ok {
+		d.client = providerData.client
+	} else {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (d *DataSourceQuota) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Unconfigured OMDb API client",
+			"Expected a configured OMDb API client. Please report this issue to the provider developers.")
+		return
+	}
+	if d.client.quota == nil {
+		resp.Diagnostics.AddError("quota tracking unavailable", "the provider is not tracking OMDb API requests")
+		return
+	}
+
+	// with several API keys, usage and budget are totals across all keys
+	var usage quotaUsage
+	for _, apiKey := range d.client.keys.all() {
+		keyUsage, err := d.client.quota.usage(ctx, apiKey)
+		if err != nil {
+			resp.Diagnostics.AddError("error reading quota", err.Error())
+			return
+		}
+		usage.used += keyUsage.used
+		usage.budget += keyUsage.budget
+		usage.resetsAt = keyUsage.resetsAt
+	}
+
+	state := quotaData{
+		Used:      types.Int64{Value: usage.used},
+		Budget:    types.Int64{Null: true},
+		Remaining: types.Int64{Null: true},
+		ResetsAt:  types.String{Value: usage.resetsAt.Format(time.RFC3339)},
+	}
+	if remaining, ok := usage.remaining(); ok {
+		state.Budget = types.Int64{Value: usage.budget}
+		state.Remaining = types.Int64{Value: remaining}
+	}
+
+	// Set state
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,130 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// viewingsData is a terraform config/plan/state style object
+type viewingsData struct {
+	Total types.Int64                 `tfsdk:"total"`
+	Films map[string]viewingsFilmData `tfsdk:"films"`
+}
+
+type viewingsFilmData struct {
+	FilmId      types.String `tfsdk:"film_id"`
+	ImdbId      types.String `tfsdk:"imdb_id"`
+	Viewings    types.Int64  `tfsdk:"viewings"`
+	LastWatched types.String `tfsdk:"last_watched"`
+}
+
+var _ datasource.DataSourceWithConfigure = &DataSourceViewings{}
+
+// DataSourceViewings implements the datasource.DataSourceWithConfigure interface
+type DataSourceViewings struct {
+	library *Library
+}
+
+func (d *DataSourceViewings) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_viewings"
+}
+
+func (d *DataSourceViewings) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Data Source totals the viewings recorded by `omdb_viewing` resources, per film. " +
+			"Removed viewings are not counted. Reading it does not make a request.",
+		Attributes: map[string]tfsdk.Attribute{
+			"total": {
+				MarkdownDescription: "Number of viewings of all films",
+				Computed:            true,
+				Type:                types.Int64Type,
+			},
+			"films": {
+				MarkdownDescription: "Films which have been watched, keyed by `film_id` or, for viewings recorded by IMDb ID, `imdb_id`. " +
+					"Film files don't record IMDb IDs, so a film with viewings recorded both ways is listed under both keys.",
+				Computed: true,
+				Attributes: tfsdk.MapNestedAttributes(map[string]tfsdk.Attribute{
+					"film_id": {
+						MarkdownDescription: "ID of the `omdb_film`, null for viewings recorded by IMDb ID",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"imdb_id": {
+						MarkdownDescription: "IMDb ID, null for viewings recorded by film ID",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+					"viewings": {
+						MarkdownDescription: "Number of viewings of the film",
+						Computed:            true,
+						Type:                types.Int64Type,
+					},
+					"last_watched": {
+						MarkdownDescription: "Date of the most recent viewing as YYYY-MM-DD",
+						Computed:            true,
+						Type:                types.StringType,
+					},
+				}),
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (d *DataSourceViewings) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerDataSourceData); ok {
+		d.library = NewLibrary(providerData.localDir)
+	} else {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (d *DataSourceViewings) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.library == nil {
+		resp.Diagnostics.AddError("Unconfigured film library",
+			"Expected a configured film library. Please report this issue to the provider developers.")
+		return
+	}
+	viewings, err := d.library.Viewings()
+	if err != nil {
+		resp.Diagnostics.AddError("error reading viewing journal", err.Error())
+		return
+	}
+
+	state := viewingsData{
+		Total: types.Int64{Value: int64(len(viewings))},
+		Films: make(map[string]viewingsFilmData),
+	}
+	for _, viewing := range viewings {
+		key := viewing.FilmId
+		if key == "" {
+			key = viewing.ImdbId
+		}
+
+		film, ok := state.Films[key]
+		if !ok {
+			film = viewingsFilmData{
+				FilmId:      types.String{Value: viewing.FilmId, Null: viewing.FilmId == ""},
+				ImdbId:      types.String{Value: viewing.ImdbId, Null: viewing.ImdbId == ""},
+				LastWatched: types.String{Value: viewing.Date},
+			}
+		}
+		film.Viewings.Value++
+		if viewing.Date > film.LastWatched.Value {
+			film.LastWatched.Value = viewing.Date
+		}
+		state.Films[key] = film
+	}
+
+	// Set state
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
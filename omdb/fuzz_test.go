@@ -0,0 +1,188 @@
+package omdb
+
+import (
+	"bytes"
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// roundTripperFunc answers HTTP requests without a network
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// addFuzzSeeds adds the response fixtures to the fuzz corpus
+func addFuzzSeeds(f *testing.F, pattern string) {
+	f.Helper()
+
+	fileNames, err := filepath.Glob(pattern)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, fileName := range fileNames {
+		b, err := os.ReadFile(fileName)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	for _, seed := range []string{
+		``,
+		`null`,
+		`{}`,
+		`{"Response":"True","Title":"N/A","Year":" n/a ","Ratings":null}`,
+		`{"Response":"True","Ratings":[{"Source":"N/A","Value":"7/10"},{"Source":" Metacritic ","Value":" 60/100 "},{}]}`,
+		`{"Response":"True","Ratings":[null,{"Source":1}]}`,
+		`{"Response":"False","Error":"Incorrect IMDb ID."}`,
+	} {
+		f.Add([]byte(seed))
+	}
+}
+
+// checkRatingsNormalized fails unless every rating has a trimmed, available
+// source and value
+func checkRatingsNormalized(t *testing.T, ratings []Rating) {
+	t.Helper()
+	for _, rating := range ratings {
+		for _, s := range []string{rating.Source, rating.Value} {
+			if isNotAvailable(s) || s != strings.TrimSpace(s) {
+				t.Fatalf("rating not normalized: %+v", rating)
+			}
+		}
+	}
+}
+
+func FuzzDecodeFilmByIdResponse(f *testing.F) {
+	addFuzzSeeds(f, filepath.Join("testdata", "response_format", "*"))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, format := range []string{responseFormatJson, responseFormatXml} {
+			var apiResponse filmByIdApiResponse
+			if decodeResponse(format, body, &apiResponse) != nil {
+				continue
+			}
+
+			normalized := normalizeRatings(apiResponse.Ratings)
+			checkRatingsNormalized(t, normalized)
+			if len(normalized) > len(apiResponse.Ratings) {
+				t.Fatalf("normalizing %d ratings produced %d", len(apiResponse.Ratings), len(normalized))
+			}
+
+			again := normalizeRatings(normalized)
+			if len(again) != len(normalized) {
+				t.Fatalf("normalizing is not idempotent: %+v became %+v", normalized, again)
+			}
+		}
+	})
+}
+
+func FuzzDataSourceFilmByIdRead(f *testing.F) {
+	addFuzzSeeds(f, filepath.Join("testdata", "response_format", "*.json"))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		ctx := context.Background()
+
+		c := newClient("http://omdb.invalid", []string{"k"})
+		c.httpClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Body:       io.NopCloser(bytes.NewReader(body)),
+				Request:    req,
+			}, nil
+		})}
+		ds := &DataSourceFilmById{client: c}
+
+		schema, diags := ds.GetSchema(ctx)
+		if diags.HasError() {
+			t.Fatalf("schema error: %v", diags)
+		}
+		objectType := schema.Type().TerraformType(ctx).(tftypes.Object)
+		config := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+		for name, attrType := range objectType.AttributeTypes {
+			config[name] = tftypes.NewValue(attrType, nil)
+		}
+		config["imdb_id"] = tftypes.NewValue(tftypes.String, "tt0080455")
+
+		resp := datasource.ReadResponse{
+			State: tfsdk.State{Schema: schema, Raw: tftypes.NewValue(objectType, nil)},
+		}
+		ds.Read(ctx, datasource.ReadRequest{
+			Config: tfsdk.Config{Schema: schema, Raw: tftypes.NewValue(objectType, config)},
+		}, &resp)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		var state filmByIdData
+		diags = resp.State.Get(ctx, &state)
+		if diags.HasError() {
+			t.Fatalf("Read set invalid state: %v", diags)
+		}
+		for _, s := range []string{state.Title.Value, state.Year.Value} {
+			if s != "" && isNotAvailable(s) {
+				t.Fatalf("Read set %q rather than null", s)
+			}
+		}
+		for _, rating := range state.Ratings0 {
+			checkRatingsNormalized(t, []Rating{{Source: rating.Source.Value, Value: rating.Value.Value}})
+		}
+	})
+}
+
+func FuzzResourceFilmRead(f *testing.F) {
+	addFuzzSeeds(f, filepath.Join("testdata", "response_format", "*.json"))
+
+	// one directory for every input; each input overwrites the same file
+	localDir := f.TempDir()
+
+	f.Fuzz(func(t *testing.T, fileData []byte) {
+		ctx := context.Background()
+		const id = "0123456789abcdef"
+
+		err := os.WriteFile(filepath.Join(localDir, id), fileData, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &ResourceFilm{library: NewLibrary(localDir)}
+
+		schema, diags := r.GetSchema(ctx)
+		if diags.HasError() {
+			t.Fatalf("schema error: %v", diags)
+		}
+		objectType := schema.Type().TerraformType(ctx).(tftypes.Object)
+		current := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+		for name, attrType := range objectType.AttributeTypes {
+			current[name] = tftypes.NewValue(attrType, nil)
+		}
+		current["id"] = tftypes.NewValue(tftypes.String, id)
+		currentState := tfsdk.State{Schema: schema, Raw: tftypes.NewValue(objectType, current)}
+
+		resp := resource.ReadResponse{State: currentState}
+		r.Read(ctx, resource.ReadRequest{State: currentState}, &resp)
+		if resp.Diagnostics.HasError() || resp.State.Raw.IsNull() {
+			return
+		}
+
+		var state filmData
+		diags = resp.State.Get(ctx, &state)
+		if diags.HasError() {
+			t.Fatalf("Read set invalid state: %v", diags)
+		}
+		if state.Id.Value != id {
+			t.Fatalf("Read changed id %q to %q", id, state.Id.Value)
+		}
+	})
+}
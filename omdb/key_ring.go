@@ -0,0 +1,95 @@
+package omdb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// keyQuarantineDuration is how long an API key which OMDb rejected (invalid
+// key, request limit reached) sits out before it is tried again
+const keyQuarantineDuration = 15 * time.Minute
+
+var errNoApiKeys = errors.New("no API keys available")
+
+// quarantinedKeysError is returned by pick when every key is quarantined. It
+// matches errNoApiKeys and wraps the error which caused the last quarantine.
+type quarantinedKeysError struct {
+	cause error
+}
+
+func (e *quarantinedKeysError) Error() string {
+	return fmt.Sprintf("%s, every API key is quarantined - %s", errNoApiKeys, e.cause)
+}
+
+func (e *quarantinedKeysError) Is(target error) bool {
+	return target == errNoApiKeys
+}
+
+func (e *quarantinedKeysError) Unwrap() error {
+	return e.cause
+}
+
+// keyRing hands out API keys round-robin, skipping keys which have been
+// quarantined after OMDb rejected them.
+type keyRing struct {
+	keys []string
+	now  func() time.Time
+
+	lock        sync.Mutex
+	next        int
+	quarantined map[string]time.Time // key -> end of quarantine
+	lastCause   error                // why the most recent key was quarantined
+}
+
+func newKeyRing(keys []string) *keyRing {
+	return &keyRing{
+		keys:        keys,
+		now:         time.Now,
+		quarantined: make(map[string]time.Time),
+	}
+}
+
+// all returns every key in the ring, quarantined or not
+func (k *keyRing) all() []string {
+	return k.keys
+}
+
+// pick returns the next key which is not quarantined. When every key is
+// quarantined, the error matches errNoApiKeys and wraps the reason for the
+// most recent quarantine.
+func (k *keyRing) pick() (string, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	now := k.now()
+	for range k.keys {
+		key := k.keys[k.next]
+		k.next = (k.next + 1) % len(k.keys)
+
+		if until, ok := k.quarantined[key]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(k.quarantined, key)
+		}
+
+		return key, nil
+	}
+
+	if k.lastCause != nil {
+		return "", &quarantinedKeysError{cause: k.lastCause}
+	}
+	return "", errNoApiKeys
+}
+
+// quarantine takes key out of rotation for keyQuarantineDuration because of
+// cause, the error OMDb returned for it
+func (k *keyRing) quarantine(key string, cause error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.quarantined[key] = k.now().Add(keyQuarantineDuration)
+	k.lastCause = cause
+}
@@ -0,0 +1,53 @@
+package omdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyRingQuarantineExpires(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	k := newKeyRing([]string{"a", "b"})
+	k.now = func() time.Time { return now }
+
+	k.quarantine("a", errInvalidApiKey)
+	for i := 0; i < 2; i++ {
+		key, err := k.pick()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "b" {
+			t.Fatalf("expected quarantined key %q to be skipped, got %q", "a", key)
+		}
+	}
+
+	k.quarantine("b", errRequestLimit)
+	_, err := k.pick()
+	if !errors.Is(err, errNoApiKeys) || !errors.Is(err, errRequestLimit) {
+		t.Fatalf("expected errNoApiKeys wrapping the last quarantine's cause while every key is quarantined, got %v", err)
+	}
+
+	// still quarantined until the very end of the quarantine
+	now = now.Add(keyQuarantineDuration - time.Second)
+	_, err = k.pick()
+	if !errors.Is(err, errNoApiKeys) {
+		t.Fatalf("expected errNoApiKeys before the quarantine ends, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		key, err := k.pick()
+		if err != nil {
+			t.Fatalf("expected keys back in rotation once the quarantine ends, got %v", err)
+		}
+		seen[key] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("expected both keys back in rotation, got %v", seen)
+	}
+	if len(k.quarantined) != 0 {
+		t.Fatalf("expected expired quarantines to be forgotten, got %v", k.quarantined)
+	}
+}
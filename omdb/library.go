@@ -0,0 +1,332 @@
+package omdb
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// libraryIdBytes is the number of random bytes in a generated film or
+	// collection ID
+	libraryIdBytes = 8
+
+	// dateLayout is the layout of dates in film files and in state
+	dateLayout = "2006-01-02"
+)
+
+// filmFilesMu serializes changes to film files so that resources sharing a
+// film, which terraform may apply concurrently, don't lose each other's
+// changes
+var filmFilesMu sync.Mutex
+
+// Rating is a film's rating from one source, as reported by OMDb and as
+// stored in film files
+type Rating struct {
+	Source string `json:"Source"`
+	Value  string `json:"Value"`
+}
+
+// UserRating is a viewer's own rating of a film, as opposed to the critics'
+// ratings in Film.Ratings
+type UserRating struct {
+	Reviewer string `json:"Reviewer"`
+	Score    int64  `json:"Score"`
+	Review   string `json:"Review,omitempty"`
+	Date     string `json:"Date"`
+}
+
+// Film is a film as stored in a file in the library. The file is named
+// after the film's ID and holds the film as JSON.
+type Film struct {
+	Id          string            `json:"-"`
+	Title       string            `json:"Title"`
+	Year        string            `json:"Year"`
+	Poster      string            `json:"Poster,omitempty"`
+	Ratings     []Rating          `json:"Ratings,omitempty"`
+	Tags        []string          `json:"Tags,omitempty"`
+	Labels      map[string]string `json:"Labels,omitempty"`
+	UserRatings []UserRating      `json:"UserRatings,omitempty"`
+}
+
+// HasTags reports whether the film has every one of tags
+func (f *Film) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, filmTag := range f.Tags {
+			if filmTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// HasLabels reports whether the film has every one of labels, with the same
+// value
+func (f *Film) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		filmValue, ok := f.Labels[k]
+		if !ok || filmValue != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Library is the directory (the provider's local_dir) in which omdb_film
+// resources keep one file per film. It is the storage layer shared by the
+// provider and by the provider binary's subcommands.
+//
+// Files whose names begin with "." (the provider's own state files and
+// in-progress writes) are not films.
+type Library struct {
+	dir string
+}
+
+// NewLibrary returns the Library in dir. The directory is not created.
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// DefaultLibraryDir returns the local_dir the provider uses when none is
+// configured: the OMDB_LOCAL_DIR environment variable or /tmp/.omdb.
+func DefaultLibraryDir() string {
+	return envOrDefault(envLocalDir, defaultLocalDir)
+}
+
+// Dir returns the library's directory
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// FileName returns the name of the file which holds the film with the given
+// ID, or an error if the ID can't name a film file.
+func (l *Library) FileName(id string) (string, error) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid film ID %q", id)
+	}
+	return filepath.Join(l.dir, id), nil
+}
+
+// Ids returns the IDs of every film in the library, sorted.
+func (l *Library) Ids() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading library directory - %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		ids = append(ids, entry.Name())
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// Films returns every film in the library, ordered by title, year and ID.
+// Files which can't be read as films are skipped; the second return value
+// holds an error for each. The error is for failure to list the library.
+func (l *Library) Films() ([]Film, []error, error) {
+	ids, err := l.Ids()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	films := make([]Film, 0, len(ids))
+	var skipped []error
+	for _, id := range ids {
+		film, err := l.Read(id)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("skipped film %q - %w", id, err))
+			continue
+		}
+		films = append(films, *film)
+	}
+
+	sort.SliceStable(films, func(i, j int) bool {
+		a, b := films[i], films[j]
+		if !strings.EqualFold(a.Title, b.Title) {
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return a.Id < b.Id
+	})
+
+	return films, skipped, nil
+}
+
+// Read returns the film with the given ID. The error wraps os.ErrNotExist
+// when there is no such film.
+func (l *Library) Read(id string) (*Film, error) {
+	fileName, err := l.FileName(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading film file - %w", err)
+	}
+
+	var film Film
+	err = json.Unmarshal(data, &film)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing film file %q - %w", fileName, err)
+	}
+	film.Id = id
+
+	return &film, nil
+}
+
+// Create writes film to the library under a newly generated ID, which is
+// set in film.Id.
+func (l *Library) Create(film *Film) error {
+	id, err := newLibraryId()
+	if err != nil {
+		return err
+	}
+	film.Id = id
+
+	return l.Write(film)
+}
+
+// Update reads the film with the given ID, applies update to it, and writes
+// it back unless update returns an error. A file which update leaves as it
+// was is not rewritten. The error wraps os.ErrNotExist when there is no such
+// film.
+func (l *Library) Update(id string, update func(film *Film) error) error {
+	filmFilesMu.Lock()
+	defer filmFilesMu.Unlock()
+
+	film, err := l.Read(id)
+	if err != nil {
+		return err
+	}
+	before, err := json.Marshal(film)
+	if err != nil {
+		return fmt.Errorf("error marshaling film to JSON - %w", err)
+	}
+	err = update(film)
+	if err != nil {
+		return err
+	}
+
+	after, err := json.Marshal(film)
+	if err == nil && bytes.Equal(before, after) {
+		return nil
+	}
+
+	return l.write(film)
+}
+
+// Write replaces the file of the film with ID film.Id, or creates it.
+func (l *Library) Write(film *Film) error {
+	filmFilesMu.Lock()
+	defer filmFilesMu.Unlock()
+
+	return l.write(film)
+}
+
+func (l *Library) write(film *Film) error {
+	fileName, err := l.FileName(film.Id)
+	if err != nil {
+		return err
+	}
+
+	err = writeJsonFile(fileName, film)
+	if err != nil {
+		return fmt.Errorf("error writing film file - %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the film with the given ID. The error wraps os.ErrNotExist
+// when there is no such film.
+func (l *Library) Remove(id string) error {
+	fileName, err := l.FileName(id)
+	if err != nil {
+		return err
+	}
+
+	filmFilesMu.Lock()
+	defer filmFilesMu.Unlock()
+
+	err = os.Remove(fileName)
+	if err != nil {
+		return fmt.Errorf("error removing film file - %w", err)
+	}
+
+	return nil
+}
+
+// newLibraryId returns a random ID for a new film or collection
+func newLibraryId() (string, error) {
+	b := make([]byte, libraryIdBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating ID - %w", err)
+	}
+	return fmt.Sprintf("%x", b), nil
+}
+
+// writeJsonFile replaces fileName with v as indented JSON. It writes then
+// renames so that readers never see a partial file; the temporary file's
+// leading "." keeps it out of Ids(). Film, collection, quota and cassette
+// files are all written this way.
+func writeJsonFile(fileName string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling JSON - %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), fileName)
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// isNotExist reports whether err says that a film doesn't exist
+func isNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
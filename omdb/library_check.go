@@ -0,0 +1,207 @@
+package omdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// yearPattern matches OMDb style years: 1985, or a range of years for a
+	// series, e.g. 2011–2019 or 2011– while still running
+	yearPattern = regexp.MustCompile(`^\d{4}([–-](\d{4})?)?$`)
+
+	// ratingValuePattern matches OMDb style rating values: 7.9/10, 60/100
+	// or 72%
+	ratingValuePattern = regexp.MustCompile(`^\d+(\.\d+)?(/\d+(\.\d+)?|%)$`)
+)
+
+// Problem is something wrong with a file in the library, found by Check
+type Problem struct {
+	// File is the name of the file in the library directory; for films,
+	// the film ID
+	File string
+
+	// Message describes the problem
+	Message string
+
+	// Repairable problems are fixed by Repair, which rewrites the file in
+	// canonical form
+	Repairable bool
+}
+
+func (p Problem) String() string {
+	return p.File + ": " + p.Message
+}
+
+// Check examines every file in the library and returns the problems found:
+// files which aren't valid films, invalid years and ratings, formatting
+// which differs from what the provider writes, temporary files left behind
+// by interrupted writes, and films with the same title and year.
+func (l *Library) Check() ([]Problem, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading library directory - %w", err)
+	}
+
+	var problems []Problem
+	var titleYears []string                    // in the order first seen
+	titleYearFilms := make(map[string][]*Film) // keyed by case-folded title and year
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+			continue
+		case strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp"):
+			problems = append(problems, Problem{
+				File:    name,
+				Message: "orphaned temporary file left behind by an interrupted write, it may be removed",
+			})
+			continue
+		case strings.HasPrefix(name, "."):
+			continue // provider state, e.g. the quota file
+		}
+
+		film, fileProblems := l.checkFile(name)
+		problems = append(problems, fileProblems...)
+		if film != nil {
+			key := strings.ToLower(strings.TrimSpace(film.Title)) + "\x00" + film.Year
+			if titleYearFilms[key] == nil {
+				titleYears = append(titleYears, key)
+			}
+			titleYearFilms[key] = append(titleYearFilms[key], film)
+		}
+	}
+
+	var duplicates []Problem
+	for _, key := range titleYears {
+		films := titleYearFilms[key]
+		if len(films) < 2 {
+			continue
+		}
+		ids := make([]string, len(films))
+		for i, film := range films {
+			ids[i] = film.Id
+		}
+		for _, film := range films {
+			duplicates = append(duplicates, Problem{
+				File:    film.Id,
+				Message: fmt.Sprintf("%q (%s) is in the library more than once, as films %s", film.Title, film.Year, strings.Join(ids, ", ")),
+			})
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].File < duplicates[j].File })
+
+	return append(problems, duplicates...), nil
+}
+
+// checkFile returns the problems with a single film file, and the film when
+// the file could be decoded.
+func (l *Library) checkFile(id string) (*Film, []Problem) {
+	fileName := filepath.Join(l.dir, id)
+	problem := func(repairable bool, format string, a ...interface{}) Problem {
+		return Problem{File: id, Message: fmt.Sprintf(format, a...), Repairable: repairable}
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, []Problem{problem(false, "unreadable - %s", err)}
+	}
+
+	var film Film
+	err = json.Unmarshal(data, &film)
+	if err != nil {
+		return nil, []Problem{problem(false, "not a film file - %s", err)}
+	}
+	film.Id = id
+
+	var problems []Problem
+
+	// rewriting a file with unexpected fields would lose them
+	strict := json.NewDecoder(bytes.NewReader(data))
+	strict.DisallowUnknownFields()
+	err = strict.Decode(&Film{})
+	repairable := err == nil
+	if err != nil {
+		problems = append(problems, problem(false, "unexpected content - %s", err))
+	}
+
+	if strings.TrimSpace(film.Title) == "" {
+		problems = append(problems, problem(false, "no title"))
+	}
+
+	if !yearPattern.MatchString(film.Year) {
+		problems = append(problems, problem(false, "year %q is not a year (e.g. 1985) or range of years (e.g. 2011–2019)", film.Year))
+	}
+
+	// "N/A" is OMDb's placeholder for a rating it doesn't have, and is kept
+	// as the film's configuration wrote it
+	for i, rating := range film.Ratings {
+		switch {
+		case isNotAvailable(rating.Source):
+			problems = append(problems, problem(false, "rating %d has no source", i+1))
+		case isNotAvailable(rating.Value):
+		case !ratingValuePattern.MatchString(strings.TrimSpace(rating.Value)):
+			problems = append(problems, problem(false, "rating %q from %q is not a score like 7.9/10, 60/100 or 72%%", rating.Value, rating.Source))
+		}
+	}
+
+	reviewers := make(map[string]bool)
+	for _, userRating := range film.UserRatings {
+		switch {
+		case strings.TrimSpace(userRating.Reviewer) == "":
+			problems = append(problems, problem(false, "user rating with no reviewer"))
+			continue
+		case reviewers[userRating.Reviewer]:
+			problems = append(problems, problem(false, "more than one user rating by %q", userRating.Reviewer))
+		}
+		reviewers[userRating.Reviewer] = true
+		if userRating.Score < minUserRatingScore || userRating.Score > maxUserRatingScore {
+			problems = append(problems, problem(false, "user rating by %q has score %d, expected %d to %d",
+				userRating.Reviewer, userRating.Score, minUserRatingScore, maxUserRatingScore))
+		}
+		if _, err := time.Parse(dateLayout, userRating.Date); err != nil {
+			problems = append(problems, problem(false, "user rating by %q has date %q, expected YYYY-MM-DD", userRating.Reviewer, userRating.Date))
+		}
+	}
+
+	canonical, err := json.MarshalIndent(&film, "", "  ")
+	if err == nil && !bytes.Equal(data, canonical) {
+		problems = append(problems, problem(repairable, "not formatted as the provider writes film files"))
+	}
+
+	return &film, problems
+}
+
+// Repair rewrites, in canonical form, every file with a repairable problem
+// and returns the IDs of the films rewritten.
+func (l *Library) Repair(problems []Problem) ([]string, error) {
+	repaired := make(map[string]bool)
+	var ids []string
+	for _, problem := range problems {
+		if !problem.Repairable || repaired[problem.File] {
+			continue
+		}
+
+		// Write() writes in canonical form
+		film, err := l.Read(problem.File)
+		if err != nil {
+			return ids, err
+		}
+		err = l.Write(film)
+		if err != nil {
+			return ids, err
+		}
+
+		repaired[problem.File] = true
+		ids = append(ids, problem.File)
+	}
+
+	return ids, nil
+}
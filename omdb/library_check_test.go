@@ -0,0 +1,104 @@
+package omdb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLibraryCheck(t *testing.T) {
+	dir := t.TempDir()
+	library := NewLibrary(dir)
+
+	err := library.Write(&Film{Id: "good", Title: "Brazil", Year: "1985", Ratings: []Rating{{Source: "Metacritic", Value: "84/100"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		quotaFileName: `{}`,
+		".good.1.tmp": `{`,
+		"series":      "{\n  \"Title\": \"Firefly\",\n  \"Year\": \"2002–2003\"\n}",
+		"compact":     `{"Title":"brazil","Year":"1985"}`,
+		"na-rating":   "{\n  \"Title\": \"Alien\",\n  \"Year\": \"1979\",\n  \"Ratings\": [\n    {\n      \"Source\": \"IMDb\",\n      \"Value\": \"N/A\"\n    }\n  ]\n}",
+		"no-source":   "{\n  \"Title\": \"Ikiru\",\n  \"Year\": \"1952\",\n  \"Ratings\": [\n    {\n      \"Source\": \"\",\n      \"Value\": \"8.2/10\"\n    }\n  ]\n}",
+		"bad-rating":  "{\n  \"Title\": \"Heat\",\n  \"Year\": \"1995\",\n  \"Ratings\": [\n    {\n      \"Source\": \"IMDb\",\n      \"Value\": \"great\"\n    }\n  ]\n}",
+		"bad-year":    "{\n  \"Title\": \"Ran\",\n  \"Year\": \"85\"\n}",
+		"extra-field": `{"Title":"Ran","Year":"1985","Plot":"Lear"}`,
+		"not-json":    `not a film`,
+	}
+	for name, content := range files {
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := library.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type problemCount struct{ all, repairable int }
+	expected := map[string]problemCount{
+		".good.1.tmp": {all: 1},
+		"compact":     {all: 2, repairable: 1}, // formatting, duplicate of good
+		"good":        {all: 1},                // duplicate of compact
+		"no-source":   {all: 1},                // na-rating has none: N/A is kept as configured
+		"bad-rating":  {all: 1},
+		"bad-year":    {all: 1},
+		"extra-field": {all: 2}, // unexpected field, formatting
+		"not-json":    {all: 1},
+	}
+	counts := make(map[string]problemCount)
+	for _, problem := range problems {
+		count := counts[problem.File]
+		count.all++
+		if problem.Repairable {
+			count.repairable++
+		}
+		counts[problem.File] = count
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected problem counts %v, got %v from %v", expected, counts, problems)
+	}
+}
+
+func TestLibraryRepair(t *testing.T) {
+	dir := t.TempDir()
+	library := NewLibrary(dir)
+
+	err := os.WriteFile(filepath.Join(dir, "compact"), []byte(`{"Title":"Alien","Year":"1979","Ratings":[{"Source":"IMDb","Value":"N/A"},{"Source":"Metacritic","Value":" 89/100 "}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := library.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repaired, err := library.Repair(problems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repaired, []string{"compact"}) {
+		t.Fatalf("expected film compact to be repaired, got %v", repaired)
+	}
+
+	problems, err = library.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("expected no problems after repair, got %v", problems)
+	}
+
+	film, err := library.Read("compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ratings are as configured, not as OMDb responses are normalized
+	if !reflect.DeepEqual(film.Ratings, []Rating{{Source: "IMDb", Value: "N/A"}, {Source: "Metacritic", Value: " 89/100 "}}) {
+		t.Fatalf("unexpected ratings after repair: %+v", film.Ratings)
+	}
+}
@@ -0,0 +1,125 @@
+package omdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// collectionsDir is the library subdirectory holding collection files. Being
+// a directory keeps collections out of Ids().
+const collectionsDir = "collections"
+
+// Collection is a named, ordered list of films. Each collection is stored
+// as JSON in a file of its own, named after its ID, in the library's
+// collections directory.
+type Collection struct {
+	Id          string   `json:"-"`
+	Name        string   `json:"Name"`
+	Description string   `json:"Description,omitempty"`
+	FilmIds     []string `json:"FilmIds"`
+}
+
+// CollectionFileName returns the name of the file which holds the collection
+// with the given ID, or an error if the ID can't name a collection file.
+func (l *Library) CollectionFileName(id string) (string, error) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid collection ID %q", id)
+	}
+	return filepath.Join(l.dir, collectionsDir, id), nil
+}
+
+// ReadCollection returns the collection with the given ID. The error wraps
+// os.ErrNotExist when there is no such collection.
+func (l *Library) ReadCollection(id string) (*Collection, error) {
+	fileName, err := l.CollectionFileName(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading collection file - %w", err)
+	}
+
+	var collection Collection
+	err = json.Unmarshal(data, &collection)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing collection file %q - %w", fileName, err)
+	}
+	collection.Id = id
+
+	return &collection, nil
+}
+
+// CreateCollection writes collection to the library under a newly generated
+// ID, which is set in collection.Id.
+func (l *Library) CreateCollection(collection *Collection) error {
+	id, err := newLibraryId()
+	if err != nil {
+		return err
+	}
+	collection.Id = id
+
+	return l.WriteCollection(collection)
+}
+
+// WriteCollection replaces the file of the collection with ID collection.Id,
+// or creates it.
+func (l *Library) WriteCollection(collection *Collection) error {
+	fileName, err := l.CollectionFileName(collection.Id)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(fileName), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating collections directory - %w", err)
+	}
+
+	err = writeJsonFile(fileName, collection)
+	if err != nil {
+		return fmt.Errorf("error writing collection file - %w", err)
+	}
+
+	return nil
+}
+
+// RemoveCollection deletes the collection with the given ID. The error wraps
+// os.ErrNotExist when there is no such collection.
+func (l *Library) RemoveCollection(id string) error {
+	fileName, err := l.CollectionFileName(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(fileName)
+	if err != nil {
+		return fmt.Errorf("error removing collection file - %w", err)
+	}
+
+	return nil
+}
+
+// MissingFilms returns the IDs in collection.FilmIds which name no film in
+// the library, in collection order.
+func (l *Library) MissingFilms(collection *Collection) ([]string, error) {
+	var missing []string
+	for _, id := range collection.FilmIds {
+		fileName, err := l.FileName(id)
+		if err != nil {
+			missing = append(missing, id)
+			continue
+		}
+		_, err = os.Stat(fileName)
+		switch {
+		case isNotExist(err):
+			missing = append(missing, id)
+		case err != nil:
+			return nil, fmt.Errorf("error checking for film %q - %w", id, err)
+		}
+	}
+	return missing, nil
+}
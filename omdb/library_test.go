@@ -0,0 +1,139 @@
+package omdb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLibraryRoundTrip(t *testing.T) {
+	library := NewLibrary(t.TempDir())
+
+	film := &Film{
+		Title:   "Brazil",
+		Year:    "1985",
+		Ratings: []Rating{{Source: "Metacritic", Value: "84/100"}},
+	}
+	err := library.Create(film)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if film.Id == "" {
+		t.Fatal("expected Create to set an ID")
+	}
+
+	read, err := library.Read(film.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, film) {
+		t.Fatalf("read %+v, wrote %+v", read, film)
+	}
+
+	err = library.Remove(film.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = library.Read(film.Id)
+	if !isNotExist(err) {
+		t.Fatalf("expected a not-exist error after Remove, got %v", err)
+	}
+}
+
+func TestLibraryIgnoresHiddenFiles(t *testing.T) {
+	dir := t.TempDir()
+	library := NewLibrary(dir)
+
+	for _, name := range []string{quotaFileName, ".abc.123.tmp"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte("not a film"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, film := range []*Film{{Title: "b"}, {Title: "A"}} {
+		err = library.Create(film)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	films, skipped, err := library.Films()
+	if err != nil || len(skipped) != 0 {
+		t.Fatal(err, skipped)
+	}
+	if len(films) != 2 || films[0].Title != "A" || films[1].Title != "b" {
+		t.Fatalf("expected films A and b, in that order, got %+v", films)
+	}
+}
+
+func TestLibraryRejectsInvalidIds(t *testing.T) {
+	library := NewLibrary(t.TempDir())
+
+	for _, id := range []string{"", ".omdb_quota.json", "../escape", `a\b`} {
+		_, err := library.Read(id)
+		if err == nil || isNotExist(err) {
+			t.Fatalf("expected ID %q to be rejected, got %v", id, err)
+		}
+	}
+}
+
+func TestLibraryUpdateLeavesUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	library := NewLibrary(dir)
+
+	// not as the provider would format it
+	content := []byte(`{"Title":"Brazil","Year":"1985","UserRatings":[{"Reviewer":"alice","Score":8,"Date":"2022-01-01"}]}`)
+	err := os.WriteFile(filepath.Join(dir, "brazil"), content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = library.Update("brazil", func(film *Film) error {
+		i := userRatingIndex(film, "bob")
+		if i >= 0 {
+			film.UserRatings = append(film.UserRatings[:i], film.UserRatings[i+1:]...)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "brazil"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(content) {
+		t.Fatalf("expected an update which changes nothing to leave the file alone, got %s", data)
+	}
+}
+
+func TestLibraryFilmsSkipsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	library := NewLibrary(dir)
+
+	err := library.Create(&Film{Title: "Brazil", Year: "1985"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "not-json"), []byte("not a film"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	films, skipped, err := library.Films()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 1 || films[0].Title != "Brazil" {
+		t.Fatalf("expected film Brazil, got %+v", films)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), `"not-json"`) {
+		t.Fatalf("expected not-json to be skipped, got %v", skipped)
+	}
+}
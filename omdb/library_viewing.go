@@ -0,0 +1,229 @@
+package omdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// viewingsDir is the library subdirectory holding the viewing journal.
+	// Being a directory keeps the journal out of Ids().
+	viewingsDir        = "viewings"
+	viewingJournalFile = "journal.jsonl"
+
+	// maxViewingJournalLine bounds the length of a journal entry
+	maxViewingJournalLine = 1 << 20
+
+	viewingAdded   = "add"
+	viewingUpdated = "update"
+	viewingRemoved = "remove"
+)
+
+// viewingJournalMu serializes access to the viewing journal
+var viewingJournalMu sync.Mutex
+
+// Viewing is a screening of a film, identified by its ID in the library or
+// by its IMDb ID
+type Viewing struct {
+	Id        string   `json:"-"`
+	FilmId    string   `json:"FilmId,omitempty"`
+	ImdbId    string   `json:"ImdbId,omitempty"`
+	Date      string   `json:"Date"`
+	Location  string   `json:"Location,omitempty"`
+	Attendees []string `json:"Attendees"`
+}
+
+// viewingJournalEntry is one line of the viewing journal: a viewing added,
+// updated or removed. The journal is only ever appended to, so it holds
+// the full history of every viewing.
+type viewingJournalEntry struct {
+	Op       string   `json:"Op"`
+	Id       string   `json:"Id"`
+	Recorded string   `json:"Recorded"`
+	Viewing  *Viewing `json:"Viewing,omitempty"`
+}
+
+// viewingJournal returns the name of the viewing journal file
+func (l *Library) viewingJournal() string {
+	return filepath.Join(l.dir, viewingsDir, viewingJournalFile)
+}
+
+// Viewings returns every viewing which hasn't been removed, ordered by date
+// and then by the order in which they were added.
+func (l *Library) Viewings() ([]Viewing, error) {
+	viewingJournalMu.Lock()
+	defer viewingJournalMu.Unlock()
+
+	viewings, err := l.replayViewingJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(viewings, func(i, j int) bool { return viewings[i].Date < viewings[j].Date })
+	return viewings, nil
+}
+
+// ReadViewing returns the viewing with the given ID. The error wraps
+// os.ErrNotExist when there is no such viewing, or it has been removed.
+func (l *Library) ReadViewing(id string) (*Viewing, error) {
+	viewingJournalMu.Lock()
+	defer viewingJournalMu.Unlock()
+
+	return l.readViewing(id)
+}
+
+func (l *Library) readViewing(id string) (*Viewing, error) {
+	viewings, err := l.replayViewingJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, viewing := range viewings {
+		if viewing.Id == id {
+			return &viewing, nil
+		}
+	}
+
+	return nil, fmt.Errorf("error reading viewing %q - %w", id, os.ErrNotExist)
+}
+
+// AddViewing records viewing under a newly generated ID, which is set in
+// viewing.Id.
+func (l *Library) AddViewing(viewing *Viewing) error {
+	id, err := newLibraryId()
+	if err != nil {
+		return err
+	}
+	viewing.Id = id
+
+	viewingJournalMu.Lock()
+	defer viewingJournalMu.Unlock()
+
+	return l.appendViewingJournal(viewingJournalEntry{Op: viewingAdded, Id: id, Viewing: viewing})
+}
+
+// UpdateViewing records a change to the viewing with ID viewing.Id. The
+// error wraps os.ErrNotExist when there is no such viewing.
+func (l *Library) UpdateViewing(viewing *Viewing) error {
+	viewingJournalMu.Lock()
+	defer viewingJournalMu.Unlock()
+
+	_, err := l.readViewing(viewing.Id)
+	if err != nil {
+		return err
+	}
+
+	return l.appendViewingJournal(viewingJournalEntry{Op: viewingUpdated, Id: viewing.Id, Viewing: viewing})
+}
+
+// RemoveViewing records the removal of the viewing with the given ID; its
+// history stays in the journal. The error wraps os.ErrNotExist when there is
+// no such viewing.
+func (l *Library) RemoveViewing(id string) error {
+	viewingJournalMu.Lock()
+	defer viewingJournalMu.Unlock()
+
+	_, err := l.readViewing(id)
+	if err != nil {
+		return err
+	}
+
+	return l.appendViewingJournal(viewingJournalEntry{Op: viewingRemoved, Id: id})
+}
+
+// appendViewingJournal writes entry to the end of the journal, creating it
+// as needed
+func (l *Library) appendViewingJournal(entry viewingJournalEntry) error {
+	entry.Recorded = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(&entry)
+	if err != nil {
+		return fmt.Errorf("error marshaling viewing journal entry - %w", err)
+	}
+
+	fileName := l.viewingJournal()
+	err = os.MkdirAll(filepath.Dir(fileName), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating viewings directory - %w", err)
+	}
+
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening viewing journal - %w", err)
+	}
+	// one write per entry, so that an entry is never interleaved with another
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing viewing journal - %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("error writing viewing journal - %w", err)
+	}
+
+	return nil
+}
+
+// replayViewingJournal returns the viewings which the journal's entries
+// leave in place, in the order they were added
+func (l *Library) replayViewingJournal() ([]Viewing, error) {
+	f, err := os.Open(l.viewingJournal())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening viewing journal - %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var ids []string // in the order first added
+	seen := make(map[string]bool)
+	viewings := make(map[string]Viewing)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxViewingJournalLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry viewingJournalEntry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing viewing journal line %d - %w", line, err)
+		}
+
+		switch {
+		case entry.Op == viewingRemoved:
+			delete(viewings, entry.Id)
+		case (entry.Op == viewingAdded || entry.Op == viewingUpdated) && entry.Viewing != nil:
+			if !seen[entry.Id] {
+				seen[entry.Id] = true
+				ids = append(ids, entry.Id)
+			}
+			entry.Viewing.Id = entry.Id
+			viewings[entry.Id] = *entry.Viewing
+		default:
+			return nil, fmt.Errorf("error parsing viewing journal line %d - unexpected entry %q", line, entry.Op)
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading viewing journal - %w", err)
+	}
+
+	var result []Viewing
+	for _, id := range ids {
+		if viewing, ok := viewings[id]; ok {
+			result = append(result, viewing)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package omdb
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLibraryViewingJournal(t *testing.T) {
+	library := NewLibrary(t.TempDir())
+
+	first := &Viewing{FilmId: "aa11", Date: "2022-03-01", Location: "Hall", Attendees: []string{"Ann", "Bo"}}
+	second := &Viewing{ImdbId: "tt0088846", Date: "2022-02-01"}
+	for _, viewing := range []*Viewing{first, second} {
+		err := library.AddViewing(viewing)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.Location = "Annex"
+	err := library.UpdateViewing(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viewings, err := library.Viewings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(viewings, []Viewing{*second, *first}) {
+		t.Fatalf("expected viewings ordered by date, got %+v", viewings)
+	}
+
+	err = library.RemoveViewing(second.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = library.ReadViewing(second.Id)
+	if !isNotExist(err) {
+		t.Fatalf("expected a not-exist error after RemoveViewing, got %v", err)
+	}
+	err = library.RemoveViewing(second.Id)
+	if !isNotExist(err) {
+		t.Fatalf("expected a not-exist error removing a removed viewing, got %v", err)
+	}
+
+	// every change is kept
+	data, err := os.ReadFile(library.viewingJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 4 {
+		t.Fatalf("expected 4 journal entries, got %d:\n%s", lines, data)
+	}
+
+	read, err := library.ReadViewing(first.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, first) {
+		t.Fatalf("read %+v, wrote %+v", read, first)
+	}
+}
+
+func TestLibraryViewingJournalErrors(t *testing.T) {
+	library := NewLibrary(t.TempDir())
+
+	viewings, err := library.Viewings()
+	if err != nil || len(viewings) != 0 {
+		t.Fatalf("expected no viewings without a journal, got %v, %v", viewings, err)
+	}
+
+	err = library.UpdateViewing(&Viewing{Id: "missing", Date: "2022-01-01"})
+	if !isNotExist(err) {
+		t.Fatalf("expected a not-exist error updating a missing viewing, got %v", err)
+	}
+
+	err = library.AddViewing(&Viewing{FilmId: "aa11", Date: "2022-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(library.viewingJournal(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString("\n{\"Op\":\"rewind\",\"Id\":\"x\"}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = library.Viewings()
+	if err == nil || err.Error() != `error parsing viewing journal line 3 - unexpected entry "rewind"` {
+		t.Fatalf("expected an error about line 3, got %v", err)
+	}
+}
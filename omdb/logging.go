@@ -0,0 +1,34 @@
+package omdb
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// logSubsystemApi logs every OMDb API request
+	logSubsystemApi = "api"
+
+	// logSubsystemFiles logs every omdb_film file operation in local_dir
+	logSubsystemFiles = "files"
+
+	// logLevelEnvPrefix combined with a subsystem name (e.g.
+	// TF_LOG_PROVIDER_OMDB_API) sets that subsystem's log level
+	logLevelEnvPrefix = "TF_LOG_PROVIDER_OMDB"
+)
+
+// logSubsystems lists every tflog subsystem the provider writes to
+var logSubsystems = []string{logSubsystemApi, logSubsystemFiles}
+
+// newLogSubsystem returns a context carrying the named tflog subsystem. Its
+// output includes the root logger's fields (e.g. tf_req_id), and its level
+// may be set apart from the provider's with TF_LOG_PROVIDER_OMDB_<SUBSYSTEM>.
+//
+// tflog masks are per-logger: anything masked with the root logger must be
+// masked again (e.g. with redactor.Context) after this is called.
+func newLogSubsystem(ctx context.Context, subsystem string) context.Context {
+	return tflog.NewSubsystem(ctx, subsystem,
+		tflog.WithRootFields(),
+		tflog.WithLevelFromEnv(logLevelEnvPrefix, subsystem),
+	)
+}
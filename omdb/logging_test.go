@@ -0,0 +1,58 @@
+package omdb
+
+import (
+	"bytes"
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientLogsRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Response":"True","imdbID":"tt0080455","Title":"t","Year":"1980"}`))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	c := newClient(server.URL, []string{testSecretApiKey})
+	for i := 0; i < 2; i++ {
+		_, err := c.filmById(ctx, "tt0080455")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertNoSecret(t, output.String())
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []map[string]interface{}
+	for _, entry := range entries {
+		if entry["@message"] == "OMDb request" {
+			requests = append(requests, entry)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 request log entries, got %d: %v", len(requests), entries)
+	}
+
+	first, second := requests[0], requests[1]
+	if first["@module"] != "provider."+logSubsystemApi {
+		t.Fatalf("expected the request to be logged by the %q subsystem, got %v", logSubsystemApi, first["@module"])
+	}
+	if first["method"] != http.MethodGet || first["cache_hit"] != false || first["status"] != float64(http.StatusOK) {
+		t.Fatalf("unexpected request log entry: %v", first)
+	}
+	if _, ok := first["latency_ms"]; !ok {
+		t.Fatalf("request log entry has no latency: %v", first)
+	}
+	if second["cache_hit"] != true {
+		t.Fatalf("expected the repeated request to be a cache hit: %v", second)
+	}
+}
@@ -0,0 +1,40 @@
+package omdb
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"strings"
+)
+
+// notAvailable is OMDb's placeholder for a field which has no value
+const notAvailable = "N/A"
+
+// isNotAvailable reports whether an OMDb field holds no value
+func isNotAvailable(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.EqualFold(s, notAvailable)
+}
+
+// optionalString renders an OMDb field as a types.String which is null when
+// OMDb has no value for the field
+func optionalString(s string) types.String {
+	if isNotAvailable(s) {
+		return types.String{Null: true}
+	}
+	return types.String{Value: strings.TrimSpace(s)}
+}
+
+// normalizeRatings trims whitespace from ratings and drops those without
+// both a source and a value. Order is preserved.
+func normalizeRatings(ratings []Rating) []Rating {
+	var result []Rating
+	for _, rating := range ratings {
+		if isNotAvailable(rating.Source) || isNotAvailable(rating.Value) {
+			continue
+		}
+		result = append(result, Rating{
+			Source: strings.TrimSpace(rating.Source),
+			Value:  strings.TrimSpace(rating.Value),
+		})
+	}
+	return result
+}
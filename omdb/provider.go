@@ -2,22 +2,32 @@ package omdb
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/schemavalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"math/rand"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"net/url"
 	"os"
-	"time"
+	"strings"
 )
 
 const (
 	defaultBaseUrl  = "https://www.omdbapi.com"
-	urlFilmById     = "/?i=%s&apikey=%s"
 	defaultLocalDir = "/tmp/.omdb"
+
+	envApiKey   = "OMDB_API_KEY"
+	envApiUrl   = "OMDB_API_URL"
+	envLocalDir = "OMDB_LOCAL_DIR"
 )
 
 var _ provider.ProviderWithMetadata = &Provider{}
@@ -32,8 +42,8 @@ type Provider struct {
 // Configure() method and is made available to the Configure() method of
 // implementations of datasource.DataSource
 type providerDataSourceData struct {
-	apiBaseUrl string
-	apiKey     string
+	client   *client
+	localDir string
 }
 
 // providerResourceData gets instantiated in the provider.Provider's
@@ -53,25 +63,141 @@ func (p *Provider) Metadata(_ context.Context, req provider.MetadataRequest, res
 	}
 }
 
+// userAgent returns the default User-Agent header for requests to OMDb
+func (p *Provider) userAgent() string {
+	switch {
+	case p.Version != "":
+		return userAgentPrefix + p.Version
+	case p.Commit != "":
+		return userAgentPrefix + p.Commit
+	default:
+		return userAgentPrefix + "dev"
+	}
+}
+
 // GetSchema returns provider schema
 func (p *Provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
 	return tfsdk.Schema{
 		MarkdownDescription: "Top level provider markdown description.",
 		Attributes: map[string]tfsdk.Attribute{
 			"api_key": {
-				MarkdownDescription: "A free OMDb API key can be quickly generated [here](https://www.omdbapi.com/apikey.aspx).",
+				MarkdownDescription: "A free OMDb API key can be quickly generated [here](https://www.omdbapi.com/apikey.aspx). " +
+					"May also be set with `api_keys`, `api_key_file`, `api_key_command` or the `" + envApiKey + "` environment variable.",
+				Type:       types.StringType,
+				Optional:   true,
+				Sensitive:  true,
+				Validators: []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
+			},
+			"api_key_command": {
+				MarkdownDescription: "Command (as an argv list, no shell is involved) which prints the OMDb API key to stdout. " +
+					"The command is run at most once per provider process and must finish within " + apiKeyCommandTimeout.String() + ".",
+				Type:     types.ListType{ElemType: types.StringType},
+				Optional: true,
+				Validators: []tfsdk.AttributeValidator{
+					listvalidator.SizeAtLeast(1),
+					schemavalidator.ConflictsWith(path.MatchRoot("api_key"), path.MatchRoot("api_key_file")),
+				},
+			},
+			"api_key_file": {
+				MarkdownDescription: "Path to a file containing the OMDb API key. Leading and trailing whitespace is ignored.",
 				Type:                types.StringType,
-				Required:            true,
-				Validators:          []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
+				Optional:            true,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+					schemavalidator.ConflictsWith(path.MatchRoot("api_key")),
+				},
+			},
+			"api_keys": {
+				MarkdownDescription: "Several OMDb API keys to spread load across. Keys are used round-robin, and a key " +
+					"which OMDb rejects (invalid key, request limit reached) is set aside for " + keyQuarantineDuration.String() +
+					" while requests are retried with the next key.",
+				Type:      types.ListType{ElemType: types.StringType},
+				Optional:  true,
+				Sensitive: true,
+				Validators: []tfsdk.AttributeValidator{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.ValuesAre(stringvalidator.LengthAtLeast(1)),
+					schemavalidator.ConflictsWith(path.MatchRoot("api_key"), path.MatchRoot("api_key_file"), path.MatchRoot("api_key_command")),
+				},
 			},
 			"api_url": {
-				MarkdownDescription: "URL of the OMDb service, defaults to " + defaultBaseUrl,
+				MarkdownDescription: "URL of the OMDb service, defaults to the `" + envApiUrl + "` environment variable or " + defaultBaseUrl + ". " +
+					"Use `" + unixSocketScheme + ":///path/to/socket` to reach an OMDb compatible service over a unix domain socket.",
+				Type:       types.StringType,
+				Optional:   true,
+				Validators: []tfsdk.AttributeValidator{apiUrlValidator{}},
+			},
+			"ca_cert_file": {
+				MarkdownDescription: "Path to a file of PEM encoded CA certificates to trust, in addition to the system pool, when connecting to the OMDb API.",
+				Type:                types.StringType,
+				Optional:            true,
+				Validators:          []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
+			},
+			"ca_cert_pem": {
+				MarkdownDescription: "PEM encoded CA certificates to trust, in addition to the system pool, when connecting to the OMDb API.",
+				Type:                types.StringType,
+				Optional:            true,
+				Validators:          []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
+			},
+			"client_cert": {
+				MarkdownDescription: "PEM encoded client certificate presented to the OMDb API. Requires `client_key`.",
+				Type:                types.StringType,
+				Optional:            true,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+					schemavalidator.AlsoRequires(path.MatchRoot("client_key")),
+				},
+			},
+			"client_key": {
+				MarkdownDescription: "PEM encoded private key for `client_cert`.",
+				Type:                types.StringType,
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+					schemavalidator.AlsoRequires(path.MatchRoot("client_cert")),
+				},
+			},
+			"daily_request_budget": {
+				MarkdownDescription: "Maximum number of requests to make to the OMDb API with each API key per UTC day. " +
+					"Requests are counted in a state file in `local_dir`. Once the budget is spent, " +
+					"further requests fail with an error. Unlimited when not set.",
+				Type:       types.Int64Type,
+				Optional:   true,
+				Validators: []tfsdk.AttributeValidator{int64validator.AtLeast(1)},
+			},
+			"insecure_skip_verify": {
+				MarkdownDescription: "When `true`, the OMDb API's TLS certificate is not verified. Defaults to `false`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"proxy_url": {
+				MarkdownDescription: "URL of an HTTP proxy to use when connecting to the OMDb API. " +
+					"When not set, the standard `HTTPS_PROXY`, `HTTP_PROXY` and `NO_PROXY` environment variables apply.",
+				Type:       types.StringType,
+				Optional:   true,
+				Validators: []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
+			},
+			"response_format": {
+				MarkdownDescription: "Format in which the OMDb API is asked to respond, `" + responseFormatJson + "` (default) or `" + responseFormatXml + "`.",
+				Type:                types.StringType,
+				Optional:            true,
+				Validators:          []tfsdk.AttributeValidator{stringvalidator.OneOf(responseFormatJson, responseFormatXml)},
+			},
+			"user_agent": {
+				MarkdownDescription: "User-Agent header sent to the OMDb API, defaults to `" + userAgentPrefix + "<provider version>`.",
 				Type:                types.StringType,
 				Optional:            true,
 				Validators:          []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
 			},
+			"validate_on_configure": {
+				MarkdownDescription: "When `true`, the provider makes a request to the OMDb API during configuration " +
+					"to check that the service is reachable and the API key is accepted. Defaults to `false`.",
+				Type:     types.BoolType,
+				Optional: true,
+			},
 			"local_dir": {
-				MarkdownDescription: "The local directory where film \"resources\" are created, defaults to" + defaultLocalDir,
+				MarkdownDescription: "The local directory where film \"resources\" are created, defaults to the `" + envLocalDir + "` environment variable or " + defaultLocalDir,
 				Type:                types.StringType,
 				Optional:            true,
 				Validators:          []tfsdk.AttributeValidator{stringvalidator.LengthAtLeast(1)},
@@ -82,14 +208,35 @@ func (p *Provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics)
 
 // Provider configuration struct
 type providerConfig struct {
-	ApiKey   types.String `tfsdk:"api_key"`
-	ApiUrl   types.String `tfsdk:"api_url"`
-	LocalDir types.String `tfsdk:"local_dir"`
+	ApiKey        types.String `tfsdk:"api_key"`
+	ApiKeyCommand types.List   `tfsdk:"api_key_command"`
+	ApiKeyFile    types.String `tfsdk:"api_key_file"`
+	ApiKeys       types.List   `tfsdk:"api_keys"`
+	ApiUrl        types.String `tfsdk:"api_url"`
+	LocalDir      types.String `tfsdk:"local_dir"`
+
+	ResponseFormat types.String `tfsdk:"response_format"`
+
+	DailyRequestBudget types.Int64 `tfsdk:"daily_request_budget"`
+
+	CaCertFile         types.String `tfsdk:"ca_cert_file"`
+	CaCertPem          types.String `tfsdk:"ca_cert_pem"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	ProxyUrl           types.String `tfsdk:"proxy_url"`
+	UserAgent          types.String `tfsdk:"user_agent"`
+
+	ValidateOnConfigure types.Bool `tfsdk:"validate_on_configure"`
 }
 
-// Configure is supposed to run before any DataSource.Configure() or
-// Resource.Configure(), but I'm not sure it's happening.
+// Configure builds the OMDb client handed to data sources and the local
+// directory settings handed to resources. Terraform calls it before any
+// DataSource.Configure() or Resource.Configure(); TF_LOG=DEBUG shows it
+// happening.
 func (p *Provider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	tflog.Debug(ctx, "configuring OMDb provider")
+
 	// Retrieve provider data from configuration
 	var config providerConfig
 	diags := req.Config.Get(ctx, &config)
@@ -98,12 +245,25 @@ func (p *Provider) Configure(ctx context.Context, req provider.ConfigureRequest,
 		return
 	}
 
+	apiKeys, apiKeySource, diags := config.loadApiKeys(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// keep API keys out of any diagnostics and logs produced from here on
+	redact := newRedactor(apiKeys...)
+	defer func() { resp.Diagnostics = redact.Diagnostics(resp.Diagnostics) }()
+	ctx = redact.Context(ctx)
+
 	if config.ApiUrl.Null {
-		config.ApiUrl = types.String{Value: defaultBaseUrl}
+		config.ApiUrl = types.String{Value: envOrDefault(envApiUrl, defaultBaseUrl)}
+		tflog.Debug(ctx, "api_url not configured, using default", map[string]interface{}{"api_url": config.ApiUrl.Value})
 	}
 
 	if config.LocalDir.Null {
-		config.LocalDir = types.String{Value: defaultLocalDir}
+		config.LocalDir = types.String{Value: envOrDefault(envLocalDir, defaultLocalDir)}
+		tflog.Debug(ctx, "local_dir not configured, using default", map[string]interface{}{"local_dir": config.LocalDir.Value})
 	}
 
 	err := os.MkdirAll(config.LocalDir.Value, 0755)
@@ -111,11 +271,57 @@ func (p *Provider) Configure(ctx context.Context, req provider.ConfigureRequest,
 		resp.Diagnostics.AddError("error creating local directory", err.Error())
 	}
 
+	apiUrl, err := parseApiUrl(config.ApiUrl.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("api_url"), "invalid OMDb API URL", err.Error())
+		return
+	}
+
+	httpClient, diags := config.httpClient(apiUrl, p.userAgent())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	httpClient.Transport, err = newCassetteTransport(httpClient.Transport, redact)
+	if err != nil {
+		resp.Diagnostics.AddError("error configuring OMDb request cassette", err.Error())
+		return
+	}
+
+	omdbClient := newClient(requestBaseUrl(apiUrl), apiKeys)
+	omdbClient.httpClient = httpClient
+	if !config.ResponseFormat.Null {
+		omdbClient.format = config.ResponseFormat.Value
+	}
+	// replayed responses don't cost anything against the daily quota
+	if cassette, ok := httpClient.Transport.(*cassetteTransport); !ok || cassette.mode != cassetteModeReplay {
+		omdbClient.quota = newQuotaTracker(config.LocalDir.Value, config.DailyRequestBudget.Value)
+	}
+	if config.ValidateOnConfigure.Value {
+		tflog.Debug(ctx, "validating OMDb API URL and key", map[string]interface{}{"api_url": config.ApiUrl.Value})
+		resp.Diagnostics.Append(validateClient(ctx, omdbClient, config.ApiUrl.Value, apiKeySource)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	tflog.Info(ctx, "configured OMDb provider", map[string]interface{}{
+		"api_url":               config.ApiUrl.Value,
+		"api_key_count":         len(apiKeys),
+		"local_dir":             config.LocalDir.Value,
+		"response_format":       omdbClient.format,
+		"daily_request_budget":  config.DailyRequestBudget.Value,
+		"quota_tracking":        omdbClient.quota != nil,
+		"validate_on_configure": config.ValidateOnConfigure.Value,
+		"cassette_mode":         os.Getenv(envCassetteMode),
+	})
+
 	// data we intend to make available to the Configure() method of
 	// implementations of datasource.DataSource
 	resp.DataSourceData = &providerDataSourceData{
-		apiBaseUrl: config.ApiUrl.Value,
-		apiKey:     config.ApiKey.Value,
+		client:   omdbClient,
+		localDir: config.LocalDir.Value,
 	}
 
 	// data we intend to make available to the Configure() method of
@@ -123,15 +329,174 @@ func (p *Provider) Configure(ctx context.Context, req provider.ConfigureRequest,
 	resp.ResourceData = &providerResourceData{
 		localDir: config.LocalDir.Value,
 	}
+}
+
+// loadApiKeys returns the keys from the api_keys attribute or, when that is
+// not set, the single key found by loadApiKey. It also returns the provider
+// attribute the keys came from, which is empty for the environment variable.
+func (c *providerConfig) loadApiKeys(ctx context.Context) ([]string, string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// a key which is only known after apply (one taken from a resource, say)
+	// would otherwise be passed over for the next source without a word
+	for _, source := range []struct {
+		attribute string
+		unknown   bool
+	}{
+		{"api_keys", c.ApiKeys.Unknown},
+		{"api_key", c.ApiKey.Unknown},
+		{"api_key_file", c.ApiKeyFile.Unknown},
+		{"api_key_command", c.ApiKeyCommand.Unknown},
+	} {
+		if source.unknown {
+			diags.AddAttributeError(path.Root(source.attribute), "unknown OMDb API key",
+				fmt.Sprintf("The provider attribute %q depends on a value which isn't known until apply, so the "+
+					"provider can't be configured. Set it to a value known at plan time, or apply the resources it "+
+					"depends on first, e.g. with -target.", source.attribute))
+		}
+	}
+	if diags.HasError() {
+		return nil, "", diags
+	}
+
+	if !c.ApiKeys.Null && !c.ApiKeys.Unknown {
+		var keys []string
+		diags.Append(c.ApiKeys.ElementsAs(ctx, &keys, false)...)
+		tflog.Debug(ctx, "using OMDb API keys", map[string]interface{}{"source": "api_keys", "count": len(keys)})
+		return keys, "api_keys", diags
+	}
+
+	source, keyDiags := c.loadApiKey(ctx)
+	diags.Append(keyDiags...)
+	if diags.HasError() {
+		return nil, "", diags
+	}
 
-	rand.Seed(time.Now().UnixNano())
+	return []string{c.ApiKey.Value}, source, diags
+}
+
+// loadApiKey populates config.ApiKey from the first available source: the
+// api_key attribute, the file named by api_key_file, the output of
+// api_key_command, or the OMDB_API_KEY environment variable. It returns the
+// provider attribute used, which is empty for the environment variable.
+func (c *providerConfig) loadApiKey(ctx context.Context) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if !c.ApiKey.Null && !c.ApiKey.Unknown {
+		tflog.Debug(ctx, "using OMDb API key", map[string]interface{}{"source": "api_key"})
+		return "api_key", diags
+	}
+
+	if !c.ApiKeyFile.Null && !c.ApiKeyFile.Unknown {
+		data, err := os.ReadFile(c.ApiKeyFile.Value)
+		if err != nil {
+			diags.AddAttributeError(path.Root("api_key_file"), "error reading API key file", err.Error())
+			return "", diags
+		}
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			diags.AddAttributeError(path.Root("api_key_file"), "empty API key file",
+				fmt.Sprintf("file %q does not contain an API key", c.ApiKeyFile.Value))
+			return "", diags
+		}
+		c.ApiKey = types.String{Value: key}
+		tflog.Debug(ctx, "using OMDb API key", map[string]interface{}{"source": "api_key_file", "file": c.ApiKeyFile.Value})
+		return "api_key_file", diags
+	}
+
+	if !c.ApiKeyCommand.Null && !c.ApiKeyCommand.Unknown {
+		var argv []string
+		diags.Append(c.ApiKeyCommand.ElementsAs(ctx, &argv, false)...)
+		if diags.HasError() {
+			return "", diags
+		}
+		key, err := runApiKeyCommand(ctx, argv)
+		if err != nil {
+			diags.AddAttributeError(path.Root("api_key_command"), "error running API key command", err.Error())
+			return "", diags
+		}
+		c.ApiKey = types.String{Value: key}
+		tflog.Debug(ctx, "using OMDb API key", map[string]interface{}{"source": "api_key_command", "command": argv[0]})
+		return "api_key_command", diags
+	}
+
+	if key := strings.TrimSpace(os.Getenv(envApiKey)); key != "" {
+		c.ApiKey = types.String{Value: key}
+		tflog.Debug(ctx, "using OMDb API key", map[string]interface{}{"source": envApiKey})
+		return "", diags
+	}
+
+	diags.AddAttributeError(path.Root("api_key"), "missing OMDb API key",
+		"An OMDb API key is required, but none was found. The following sources were checked, in order:\n"+
+			"  - provider attribute \"api_keys\"\n"+
+			"  - provider attribute \"api_key\"\n"+
+			"  - file named by provider attribute \"api_key_file\"\n"+
+			"  - output of provider attribute \"api_key_command\"\n"+
+			"  - environment variable "+envApiKey)
+	return "", diags
+}
+
+// validateClient probes the OMDb API at apiUrl and translates failures into
+// diagnostics against the provider attribute most likely responsible.
+// apiKeySource is the attribute the API keys came from, or empty when they
+// came from the environment.
+func validateClient(ctx context.Context, c *client, apiUrl string, apiKeySource string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	keyError := func(summary string, detail string) {
+		if apiKeySource == "" {
+			diags.AddError(summary, detail+" (from environment variable "+envApiKey+")")
+			return
+		}
+		diags.AddAttributeError(path.Root(apiKeySource), summary, detail)
+	}
+
+	err := c.probe(ctx)
+	if err == nil {
+		return diags
+	}
+
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &urlErr):
+		diags.AddAttributeError(path.Root("api_url"), "OMDb API unreachable",
+			fmt.Sprintf("could not reach the OMDb API at %q - %s", apiUrl, err.Error()))
+	case errors.Is(err, errInvalidApiKey):
+		keyError("invalid OMDb API key",
+			fmt.Sprintf("the OMDb API at %q rejected the API key - %s", apiUrl, err.Error()))
+	case errors.Is(err, errRequestLimit):
+		keyError("OMDb API request limit reached",
+			fmt.Sprintf("the OMDb API at %q reports that the API key's request quota is exhausted - %s", apiUrl, err.Error()))
+	case errors.Is(err, errBudgetExhausted) || errors.Is(err, errNoApiKeys):
+		diags.AddAttributeError(path.Root("daily_request_budget"), "OMDb daily request budget exhausted",
+			fmt.Sprintf("no API key has requests left in its daily budget - %s", err.Error()))
+	default:
+		diags.AddAttributeError(path.Root("api_url"), "unexpected response from OMDb API",
+			fmt.Sprintf("the OMDb API at %q did not respond as expected - %s", apiUrl, err.Error()))
+	}
+
+	return diags
+}
+
+// envOrDefault returns the value of the named environment variable, or
+// def if the variable is unset or empty.
+func envOrDefault(name string, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
 }
 
 // DataSources defines provider data sources by returning a slice of functions
 // which return data sources.
 func (p *Provider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
+		func() datasource.DataSource { return &DataSourceCsvFilms{} },
 		func() datasource.DataSource { return &DataSourceFilmById{} },
+		func() datasource.DataSource { return &DataSourceFilmsByIds{} },
+		func() datasource.DataSource { return &DataSourceLibraryFilms{} },
+		func() datasource.DataSource { return &DataSourceQuota{} },
+		func() datasource.DataSource { return &DataSourceViewings{} },
 	}
 }
 
@@ -139,6 +504,9 @@ func (p *Provider) DataSources(_ context.Context) []func() datasource.DataSource
 // which return resources.
 func (p *Provider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
+		func() resource.Resource { return &ResourceCollection{} },
 		func() resource.Resource { return &ResourceFilm{} },
+		func() resource.Resource { return &ResourceUserRating{} },
+		func() resource.Resource { return &ResourceViewing{} },
 	}
 }
@@ -0,0 +1,1360 @@
+package omdb
+
+import (
+	"context"
+	"github.com/chrismarget/terraform-provider-omdb/omdbmock"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFixtureDir = "../omdbmock/testdata"
+
+// protocolHarness drives the provider through the same tfprotov6 calls
+// terraform makes, without a terraform binary.
+type protocolHarness struct {
+	t       *testing.T
+	server  tfprotov6.ProviderServer
+	schemas *tfprotov6.GetProviderSchemaResponse
+}
+
+func newProtocolHarness(t *testing.T) *protocolHarness {
+	t.Helper()
+
+	server, err := providerserver.NewProtocol6WithError(&Provider{Version: "test"})()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &protocolHarness{t: t, server: server, schemas: schemas}
+	h.checkDiagnostics("GetProviderSchema", schemas.Diagnostics)
+
+	return h
+}
+
+// newMockOmdb starts an OMDb stand-in serving the omdbmock fixtures
+func newMockOmdb(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	handler, err := omdbmock.New(omdbmock.Options{FixtureDir: testFixtureDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (h *protocolHarness) checkDiagnostics(call string, diags []*tfprotov6.Diagnostic) {
+	h.t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			h.t.Fatalf("%s: %s: %s", call, d.Summary, d.Detail)
+		}
+	}
+}
+
+// object builds a value of the schema's type from the supplied attribute
+// values; all other attributes are null.
+func (h *protocolHarness) object(schema *tfprotov6.Schema, attrs map[string]tftypes.Value) tftypes.Value {
+	h.t.Helper()
+
+	objectType := schema.ValueType().(tftypes.Object)
+	vals := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		if v, ok := attrs[name]; ok {
+			vals[name] = v
+		} else {
+			vals[name] = tftypes.NewValue(attrType, nil)
+		}
+	}
+
+	return tftypes.NewValue(objectType, vals)
+}
+
+func (h *protocolHarness) dynamicValue(schema *tfprotov6.Schema, v tftypes.Value) *tfprotov6.DynamicValue {
+	h.t.Helper()
+
+	dv, err := tfprotov6.NewDynamicValue(schema.ValueType(), v)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return &dv
+}
+
+func (h *protocolHarness) value(schema *tfprotov6.Schema, dv *tfprotov6.DynamicValue) tftypes.Value {
+	h.t.Helper()
+
+	if dv == nil {
+		return tftypes.NewValue(schema.ValueType(), nil)
+	}
+	v, err := dv.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return v
+}
+
+func (h *protocolHarness) configure(attrs map[string]tftypes.Value) {
+	h.t.Helper()
+
+	h.checkDiagnostics("ConfigureProvider", h.configureDiagnostics(attrs))
+}
+
+// configureDiagnostics is configure, returning the diagnostics rather than
+// failing on errors
+func (h *protocolHarness) configureDiagnostics(attrs map[string]tftypes.Value) []*tfprotov6.Diagnostic {
+	h.t.Helper()
+
+	schema := h.schemas.Provider
+	resp, err := h.server.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{
+		Config: h.dynamicValue(schema, h.object(schema, attrs)),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return resp.Diagnostics
+}
+
+// configureLocal configures the provider for resources and data sources
+// which work on local_dir alone. The API URL refuses connections, so any
+// request to OMDb fails.
+func (h *protocolHarness) configureLocal(localDir string) {
+	h.t.Helper()
+
+	h.configure(map[string]tftypes.Value{
+		"api_key":   stringValue("k"),
+		"api_url":   stringValue("http://127.0.0.1:1"),
+		"local_dir": stringValue(localDir),
+	})
+}
+
+func (h *protocolHarness) readDataSource(typeName string, attrs map[string]tftypes.Value) map[string]tftypes.Value {
+	h.t.Helper()
+
+	state, diags := h.readDataSourceDiagnostics(context.Background(), typeName, attrs)
+	h.checkDiagnostics("ReadDataSource", diags)
+
+	return state
+}
+
+// readDataSourceDiagnostics is readDataSource with a context, returning the
+// diagnostics rather than failing on errors. The state is nil when the read
+// produced none.
+func (h *protocolHarness) readDataSourceDiagnostics(ctx context.Context, typeName string, attrs map[string]tftypes.Value) (map[string]tftypes.Value, []*tfprotov6.Diagnostic) {
+	h.t.Helper()
+
+	schema := h.schemas.DataSourceSchemas[typeName]
+	resp, err := h.server.ReadDataSource(ctx, &tfprotov6.ReadDataSourceRequest{
+		TypeName: typeName,
+		Config:   h.dynamicValue(schema, h.object(schema, attrs)),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if resp.State == nil {
+		return nil, resp.Diagnostics
+	}
+
+	return h.attributes(h.value(schema, resp.State)), resp.Diagnostics
+}
+
+// validateResource validates the configuration made up of attrs, returning
+// the diagnostics rather than failing on errors
+func (h *protocolHarness) validateResource(typeName string, attrs map[string]tftypes.Value) []*tfprotov6.Diagnostic {
+	h.t.Helper()
+
+	schema := h.schemas.ResourceSchemas[typeName]
+	resp, err := h.server.ValidateResourceConfig(context.Background(), &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: typeName,
+		Config:   h.dynamicValue(schema, h.object(schema, attrs)),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return resp.Diagnostics
+}
+
+// planResource plans a change from prior to the configuration made up of
+// attrs (nil attrs means destroy), returning the planned state.
+func (h *protocolHarness) planResource(typeName string, prior tftypes.Value, attrs map[string]tftypes.Value) tftypes.Value {
+	h.t.Helper()
+
+	schema := h.schemas.ResourceSchemas[typeName]
+	config, proposed := h.proposeResource(typeName, prior, attrs)
+	resp, err := h.server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       h.dynamicValue(schema, prior),
+		ProposedNewState: h.dynamicValue(schema, proposed),
+		Config:           h.dynamicValue(schema, config),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.checkDiagnostics("PlanResourceChange", resp.Diagnostics)
+
+	return h.value(schema, resp.PlannedState)
+}
+
+// applyResource plans and applies a change from prior to the configuration
+// made up of attrs (nil attrs means destroy), returning the new state.
+func (h *protocolHarness) applyResource(typeName string, prior tftypes.Value, attrs map[string]tftypes.Value) tftypes.Value {
+	h.t.Helper()
+
+	schema := h.schemas.ResourceSchemas[typeName]
+	config, _ := h.proposeResource(typeName, prior, attrs)
+	planned := h.planResource(typeName, prior, attrs)
+	resp, err := h.server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   h.dynamicValue(schema, prior),
+		PlannedState: h.dynamicValue(schema, planned),
+		Config:       h.dynamicValue(schema, config),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.checkDiagnostics("ApplyResourceChange", resp.Diagnostics)
+
+	return h.value(schema, resp.NewState)
+}
+
+// proposeResource returns the configuration made up of attrs and the new
+// state terraform would propose for it, given prior
+func (h *protocolHarness) proposeResource(typeName string, prior tftypes.Value, attrs map[string]tftypes.Value) (tftypes.Value, tftypes.Value) {
+	h.t.Helper()
+
+	schema := h.schemas.ResourceSchemas[typeName]
+	if attrs == nil {
+		none := tftypes.NewValue(schema.ValueType(), nil)
+		return none, none
+	}
+
+	// terraform proposes computed attributes which aren't configured from
+	// the prior state
+	proposedAttrs := make(map[string]tftypes.Value, len(attrs)+1)
+	for k, v := range attrs {
+		proposedAttrs[k] = v
+	}
+	if !prior.IsNull() {
+		priorAttrs := h.attributes(prior)
+		for _, attribute := range schema.Block.Attributes {
+			if _, ok := attrs[attribute.Name]; attribute.Computed && !ok {
+				proposedAttrs[attribute.Name] = priorAttrs[attribute.Name]
+			}
+		}
+	}
+
+	return h.object(schema, attrs), h.object(schema, proposedAttrs)
+}
+
+func (h *protocolHarness) readResource(typeName string, state tftypes.Value) tftypes.Value {
+	h.t.Helper()
+
+	newState, diags := h.readResourceDiagnostics(typeName, state)
+	h.checkDiagnostics("ReadResource", diags)
+
+	return newState
+}
+
+// readResourceDiagnostics is readResource, returning the diagnostics rather
+// than failing on errors
+func (h *protocolHarness) readResourceDiagnostics(typeName string, state tftypes.Value) (tftypes.Value, []*tfprotov6.Diagnostic) {
+	h.t.Helper()
+
+	schema := h.schemas.ResourceSchemas[typeName]
+	resp, err := h.server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: h.dynamicValue(schema, state),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return h.value(schema, resp.NewState), resp.Diagnostics
+}
+
+func (h *protocolHarness) importResource(typeName string, id string) tftypes.Value {
+	h.t.Helper()
+
+	schema := h.schemas.ResourceSchemas[typeName]
+	resp, err := h.server.ImportResourceState(context.Background(), &tfprotov6.ImportResourceStateRequest{
+		TypeName: typeName,
+		ID:       id,
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.checkDiagnostics("ImportResourceState", resp.Diagnostics)
+	if len(resp.ImportedResources) != 1 {
+		h.t.Fatalf("expected 1 imported resource, got %d", len(resp.ImportedResources))
+	}
+
+	// terraform follows an import with a read
+	return h.readResource(typeName, h.value(schema, resp.ImportedResources[0].State))
+}
+
+func (h *protocolHarness) attributes(v tftypes.Value) map[string]tftypes.Value {
+	h.t.Helper()
+
+	var attrs map[string]tftypes.Value
+	err := v.As(&attrs)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return attrs
+}
+
+func (h *protocolHarness) stringAttribute(v tftypes.Value, name string) string {
+	h.t.Helper()
+
+	var s string
+	err := h.attributes(v)[name].As(&s)
+	if err != nil {
+		h.t.Fatalf("attribute %q: %s", name, err)
+	}
+
+	return s
+}
+
+func stringValue(s string) tftypes.Value {
+	return tftypes.NewValue(tftypes.String, s)
+}
+
+func TestProviderConfigureDefaultsFromEnvironment(t *testing.T) {
+	mock := newMockOmdb(t)
+	localDir := filepath.Join(t.TempDir(), "films")
+	t.Setenv(envApiKey, "k")
+	t.Setenv(envApiUrl, mock.URL)
+	t.Setenv(envLocalDir, localDir)
+
+	h := newProtocolHarness(t)
+	h.configure(map[string]tftypes.Value{
+		"validate_on_configure": tftypes.NewValue(tftypes.Bool, true),
+	})
+
+	// local_dir is created during configuration
+	info, err := os.Stat(localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Fatalf("expected %q to be a directory", localDir)
+	}
+
+	// requests go to api_url
+	film := h.readDataSource("omdb_film_by_id", map[string]tftypes.Value{"imdb_id": stringValue("tt0080455")})
+	var title string
+	err = film["title"].As(&title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "The Blues Brothers" {
+		t.Fatalf("expected title %q, got %q", "The Blues Brothers", title)
+	}
+}
+
+func TestProviderConfigureRejectsUnknownApiKey(t *testing.T) {
+	t.Setenv(envApiKey, "from-environment")
+
+	h := newProtocolHarness(t)
+	diags := h.configureDiagnostics(map[string]tftypes.Value{
+		"api_key":   tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"local_dir": stringValue(t.TempDir()),
+	})
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError ||
+		!diags[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName("api_key")) {
+		t.Fatalf("expected an error about api_key, got %v", diags)
+	}
+}
+
+func TestProviderConfigureApiKeySources(t *testing.T) {
+	t.Setenv(envApiKey, "")
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	emptyFile := filepath.Join(dir, "empty")
+	for name, content := range map[string]string{keyFile: " good\n", emptyFile: " \n"} {
+		err := os.WriteFile(name, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the key from api_key_file is the one sent to OMDb
+	handler, err := omdbmock.New(omdbmock.Options{FixtureDir: testFixtureDir, ApiKeys: []string{"good"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := httptest.NewServer(handler)
+	t.Cleanup(mock.Close)
+	h := newProtocolHarness(t)
+	h.configure(map[string]tftypes.Value{
+		"api_key_file":          stringValue(keyFile),
+		"api_url":               stringValue(mock.URL),
+		"local_dir":             stringValue(t.TempDir()),
+		"validate_on_configure": tftypes.NewValue(tftypes.Bool, true),
+	})
+
+	for name, tc := range map[string]struct {
+		attrs     map[string]tftypes.Value
+		attribute string
+		summary   string
+		detail    []string
+	}{
+		"empty_file": {
+			attrs:     map[string]tftypes.Value{"api_key_file": stringValue(emptyFile)},
+			attribute: "api_key_file",
+			summary:   "empty API key file",
+		},
+		"unreadable_file": {
+			attrs:     map[string]tftypes.Value{"api_key_file": stringValue(filepath.Join(dir, "missing"))},
+			attribute: "api_key_file",
+			summary:   "error reading API key file",
+		},
+		"missing": {
+			attrs:     map[string]tftypes.Value{},
+			attribute: "api_key",
+			summary:   "missing OMDb API key",
+			detail:    []string{`"api_keys"`, `"api_key"`, `"api_key_file"`, `"api_key_command"`, envApiKey},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			attrs := map[string]tftypes.Value{"local_dir": stringValue(t.TempDir())}
+			for k, v := range tc.attrs {
+				attrs[k] = v
+			}
+
+			diags := newProtocolHarness(t).configureDiagnostics(attrs)
+			if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError || diags[0].Summary != tc.summary ||
+				!diags[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName(tc.attribute)) {
+				t.Fatalf("expected a %q error about %s, got %v", tc.summary, tc.attribute, diags)
+			}
+			for _, source := range tc.detail {
+				if !strings.Contains(diags[0].Detail, source) {
+					t.Fatalf("expected the sources checked to include %s, got %q", source, diags[0].Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderValidateOnConfigureFailures(t *testing.T) {
+	handler, err := omdbmock.New(omdbmock.Options{
+		FixtureDir: testFixtureDir,
+		ApiKeys:    []string{"good", "limited"},
+		Faults:     []omdbmock.Fault{{Match: map[string]string{"apikey": "limited"}, Error: "Request limit reached!"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := httptest.NewServer(handler)
+	t.Cleanup(mock.Close)
+
+	// the probe spends the only request in the budget
+	spentDir := t.TempDir()
+	newProtocolHarness(t).configure(map[string]tftypes.Value{
+		"api_key":               stringValue("good"),
+		"api_url":               stringValue(mock.URL),
+		"local_dir":             stringValue(spentDir),
+		"daily_request_budget":  tftypes.NewValue(tftypes.Number, 1),
+		"validate_on_configure": tftypes.NewValue(tftypes.Bool, true),
+	})
+
+	for name, tc := range map[string]struct {
+		attrs     map[string]tftypes.Value
+		attribute string
+		summary   string
+	}{
+		"unreachable": {
+			attrs:     map[string]tftypes.Value{"api_key": stringValue("good"), "api_url": stringValue("http://127.0.0.1:1")},
+			attribute: "api_url",
+			summary:   "OMDb API unreachable",
+		},
+		"invalid_api_key": {
+			attrs:     map[string]tftypes.Value{"api_key": stringValue("bad")},
+			attribute: "api_key",
+			summary:   "invalid OMDb API key",
+		},
+		"invalid_api_keys": {
+			attrs: map[string]tftypes.Value{"api_keys": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+				stringValue("bad1"), stringValue("bad2"),
+			})},
+			attribute: "api_keys",
+			summary:   "invalid OMDb API key",
+		},
+		"request_limit": {
+			attrs:     map[string]tftypes.Value{"api_key": stringValue("limited")},
+			attribute: "api_key",
+			summary:   "OMDb API request limit reached",
+		},
+		"budget_exhausted": {
+			attrs: map[string]tftypes.Value{
+				"api_key":              stringValue("good"),
+				"local_dir":            stringValue(spentDir),
+				"daily_request_budget": tftypes.NewValue(tftypes.Number, 1),
+			},
+			attribute: "daily_request_budget",
+			summary:   "OMDb daily request budget exhausted",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			attrs := map[string]tftypes.Value{
+				"api_url":               stringValue(mock.URL),
+				"local_dir":             stringValue(t.TempDir()),
+				"validate_on_configure": tftypes.NewValue(tftypes.Bool, true),
+			}
+			for k, v := range tc.attrs {
+				attrs[k] = v
+			}
+
+			h := newProtocolHarness(t)
+			diags := h.configureDiagnostics(attrs)
+			if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError || diags[0].Summary != tc.summary ||
+				!diags[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName(tc.attribute)) {
+				t.Fatalf("expected a %q error about %s, got %v", tc.summary, tc.attribute, diags)
+			}
+		})
+	}
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv(envApiKey, "bad")
+
+		h := newProtocolHarness(t)
+		diags := h.configureDiagnostics(map[string]tftypes.Value{
+			"api_url":               stringValue(mock.URL),
+			"local_dir":             stringValue(t.TempDir()),
+			"validate_on_configure": tftypes.NewValue(tftypes.Bool, true),
+		})
+		if len(diags) != 1 || diags[0].Summary != "invalid OMDb API key" || diags[0].Attribute != nil {
+			t.Fatalf("expected an invalid key error without an attribute, got %v", diags)
+		}
+	})
+}
+
+func TestDataSourceFilmByIdRead(t *testing.T) {
+	mock := newMockOmdb(t)
+
+	h := newProtocolHarness(t)
+	h.configure(map[string]tftypes.Value{
+		"api_key":   stringValue("k"),
+		"api_url":   stringValue(mock.URL),
+		"local_dir": stringValue(t.TempDir()),
+	})
+
+	film := h.readDataSource("omdb_film_by_id", map[string]tftypes.Value{"imdb_id": stringValue("tt0088247")})
+
+	var imdbId, title, year string
+	for name, target := range map[string]*string{"imdb_id": &imdbId, "title": &title, "year": &year} {
+		err := film[name].As(target)
+		if err != nil {
+			t.Fatalf("attribute %q: %s", name, err)
+		}
+	}
+	if imdbId != "tt0088247" || title != "The Terminator" || year != "1984" {
+		t.Fatalf("unexpected film %q %q %q", imdbId, title, year)
+	}
+
+	var ratings []tftypes.Value
+	err := film["ratings0"].As(&ratings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) == 0 {
+		t.Fatal("expected ratings")
+	}
+}
+
+func TestDataSourceReadWithoutConfiguredProvider(t *testing.T) {
+	h := newProtocolHarness(t)
+
+	for typeName, attrs := range map[string]map[string]tftypes.Value{
+		"omdb_film_by_id": {"imdb_id": stringValue("tt0088247")},
+		"omdb_films_by_ids": {"imdb_ids": tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+			stringValue("tt0088247"),
+		})},
+		"omdb_library_films": {},
+		"omdb_viewings":      {},
+	} {
+		_, diags := h.readDataSourceDiagnostics(context.Background(), typeName, attrs)
+		if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: expected an error, got %v", typeName, diags)
+		}
+	}
+}
+
+func TestDataSourceFilmsByIdsRead(t *testing.T) {
+	mock := newMockOmdb(t)
+
+	h := newProtocolHarness(t)
+	h.configure(map[string]tftypes.Value{
+		"api_key":   stringValue("k"),
+		"api_url":   stringValue(mock.URL),
+		"local_dir": stringValue(t.TempDir()),
+	})
+
+	imdbIds := tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+		stringValue("tt0088247"), stringValue("tt9999999"),
+	})
+
+	// the unknown ID is reported in errors, the known one in films
+	state := h.readDataSource("omdb_films_by_ids", map[string]tftypes.Value{"imdb_ids": imdbIds})
+	var films, errs map[string]tftypes.Value
+	err := state["films"].As(&films)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = state["errors"].As(&errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 1 || films["tt0088247"].IsNull() {
+		t.Fatalf("expected films to hold tt0088247 alone, got %v", films)
+	}
+	if title := h.stringAttribute(films["tt0088247"], "title"); title != "The Terminator" {
+		t.Fatalf("expected title %q, got %q", "The Terminator", title)
+	}
+	if len(errs) != 1 || errs["tt9999999"].IsNull() {
+		t.Fatalf("expected errors to hold tt9999999 alone, got %v", errs)
+	}
+
+	// with fail_on_error, each unknown ID is an error
+	_, diags := h.readDataSourceDiagnostics(context.Background(), "omdb_films_by_ids", map[string]tftypes.Value{
+		"imdb_ids": tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+			stringValue("tt0088247"), stringValue("tt9999998"), stringValue("tt9999999"),
+		}),
+		"fail_on_error": tftypes.NewValue(tftypes.Bool, true),
+	})
+	if len(diags) != 2 {
+		t.Fatalf("expected an error for each unknown ID, got %v", diags)
+	}
+	for i, id := range []string{"tt9999998", "tt9999999"} {
+		if diags[i].Severity != tfprotov6.DiagnosticSeverityError || !strings.Contains(diags[i].Summary, id) ||
+			!diags[i].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName("imdb_ids")) {
+			t.Fatalf("expected an error about %s on imdb_ids, got %v", id, diags[i])
+		}
+	}
+
+	// a cancelled read reports every ID which it didn't fetch; these IDs
+	// haven't been fetched before, so aren't remembered by the client
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	state, diags = h.readDataSourceDiagnostics(ctx, "omdb_films_by_ids", map[string]tftypes.Value{
+		"imdb_ids": tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+			stringValue("tt0080455"), stringValue("tt1480055"),
+		}),
+	})
+	h.checkDiagnostics("ReadDataSource", diags)
+	err = state["errors"].As(&errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"tt0080455", "tt1480055"} {
+		var msg string
+		err = errs[id].As(&msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(msg, context.Canceled.Error()) {
+			t.Fatalf("expected %s to be reported as cancelled, got %q", id, msg)
+		}
+	}
+}
+
+func TestResourceFilmLifecycle(t *testing.T) {
+	localDir := t.TempDir()
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	const typeName = "omdb_film"
+	schema := h.schemas.ResourceSchemas[typeName]
+	none := tftypes.NewValue(schema.ValueType(), nil)
+
+	// create
+	state := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"title": stringValue("Brazil"),
+		"year":  stringValue("1985"),
+	})
+	id := h.stringAttribute(state, "id")
+	if id == "" {
+		t.Fatal("expected an id after create")
+	}
+	if _, err := os.Stat(filepath.Join(localDir, id)); err != nil {
+		t.Fatalf("expected a film file after create: %s", err)
+	}
+
+	// read
+	state = h.readResource(typeName, state)
+	if h.stringAttribute(state, "title") != "Brazil" || h.stringAttribute(state, "year") != "1985" {
+		t.Fatalf("unexpected state after read: %v", state)
+	}
+
+	// update in place
+	state = h.applyResource(typeName, state, map[string]tftypes.Value{
+		"title": stringValue("Brazil"),
+		"year":  stringValue("1986"),
+	})
+	if h.stringAttribute(state, "id") != id {
+		t.Fatalf("expected id %q to survive update, got %q", id, h.stringAttribute(state, "id"))
+	}
+	state = h.readResource(typeName, state)
+	if h.stringAttribute(state, "year") != "1986" {
+		t.Fatalf("expected year to be updated, got %q", h.stringAttribute(state, "year"))
+	}
+
+	// import
+	imported := h.importResource(typeName, id)
+	if !imported.Equal(state) {
+		t.Fatalf("imported state %v differs from state %v", imported, state)
+	}
+
+	// delete
+	state = h.applyResource(typeName, state, nil)
+	if !state.IsNull() {
+		t.Fatalf("expected null state after delete, got %v", state)
+	}
+	if _, err := os.Stat(filepath.Join(localDir, id)); !os.IsNotExist(err) {
+		t.Fatalf("expected film file to be removed, got %v", err)
+	}
+
+	// a resource whose file has gone is removed from state
+	if !h.readResource(typeName, h.object(schema, map[string]tftypes.Value{"id": stringValue(id)})).IsNull() {
+		t.Fatal("expected a missing film file to remove the resource from state")
+	}
+}
+
+func TestResourceFilmKeepsRatingsFromGeneratedConfig(t *testing.T) {
+	localDir := t.TempDir()
+	library := NewLibrary(localDir)
+	film := &Film{
+		Title:   "Brazil",
+		Year:    "1985",
+		Ratings: []Rating{{Source: "Metacritic", Value: "84/100"}},
+	}
+	err := library.Create(film)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	// generate-config describes ratings with ratings2, as Read() does
+	ratingType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"source": tftypes.String, "value": tftypes.String}}
+	ratings2 := tftypes.NewValue(tftypes.List{ElementType: ratingType}, []tftypes.Value{
+		tftypes.NewValue(ratingType, map[string]tftypes.Value{
+			"source": stringValue("Metacritic"),
+			"value":  stringValue("84/100"),
+		}),
+	})
+
+	state := h.importResource("omdb_film", film.Id)
+	if !h.attributes(state)["ratings2"].Equal(ratings2) {
+		t.Fatalf("expected imported ratings2 %v, got %v", ratings2, h.attributes(state)["ratings2"])
+	}
+
+	// updating another attribute leaves the ratings in place
+	h.applyResource("omdb_film", state, map[string]tftypes.Value{
+		"title":    stringValue("Brazil"),
+		"year":     stringValue("1986"),
+		"ratings2": ratings2,
+	})
+
+	updated, err := library.Read(film.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Year != "1986" || len(updated.Ratings) != 1 || updated.Ratings[0] != film.Ratings[0] {
+		t.Fatalf("unexpected film after update: %+v", updated)
+	}
+}
+
+func TestResourceFilmKeepsConfiguredRatings(t *testing.T) {
+	localDir := t.TempDir()
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	// ratings as OMDb reports them, placeholder and padding included
+	ratingType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"source": tftypes.String, "value": tftypes.String}}
+	rating := func(source, value string) tftypes.Value {
+		return tftypes.NewValue(ratingType, map[string]tftypes.Value{
+			"source": stringValue(source),
+			"value":  stringValue(value),
+		})
+	}
+	config := map[string]tftypes.Value{
+		"title": stringValue("Brazil"),
+		"year":  stringValue("1985"),
+		"ratings2": tftypes.NewValue(tftypes.List{ElementType: ratingType}, []tftypes.Value{
+			rating("Internet Movie Database", "N/A"),
+			rating("Metacritic", " 84/100 "),
+		}),
+	}
+
+	const typeName = "omdb_film"
+	state := h.applyResource(typeName, tftypes.NewValue(h.schemas.ResourceSchemas[typeName].ValueType(), nil), config)
+	state = h.readResource(typeName, state)
+	if !h.attributes(state)["ratings2"].Equal(config["ratings2"]) {
+		t.Fatalf("expected ratings2 %v to be read back unchanged, got %v", config["ratings2"], h.attributes(state)["ratings2"])
+	}
+	if planned := h.planResource(typeName, state, config); !planned.Equal(state) {
+		t.Fatalf("expected no changes, planned %v from %v", planned, state)
+	}
+}
+
+func TestResourceFilmRatingsConflict(t *testing.T) {
+	h := newProtocolHarness(t)
+
+	ratingType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"source": tftypes.String, "value": tftypes.String}}
+	ratings := tftypes.NewValue(tftypes.List{ElementType: ratingType}, []tftypes.Value{
+		tftypes.NewValue(ratingType, map[string]tftypes.Value{
+			"source": stringValue("Metacritic"),
+			"value":  stringValue("84/100"),
+		}),
+	})
+
+	diags := h.validateResource("omdb_film", map[string]tftypes.Value{
+		"title":    stringValue("Brazil"),
+		"year":     stringValue("1985"),
+		"ratings0": ratings,
+		"ratings2": ratings,
+	})
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError {
+		t.Fatalf("expected an error about ratings0 and ratings2, got %v", diags)
+	}
+}
+
+func TestDataSourceQuotaRead(t *testing.T) {
+	mock := newMockOmdb(t)
+
+	h := newProtocolHarness(t)
+	h.configure(map[string]tftypes.Value{
+		"api_keys": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			stringValue("a"), stringValue("b"),
+		}),
+		"api_url":              stringValue(mock.URL),
+		"local_dir":            stringValue(t.TempDir()),
+		"daily_request_budget": tftypes.NewValue(tftypes.Number, 5),
+	})
+
+	for _, id := range []string{"tt0088247", "tt0080455", "tt1480055"} {
+		h.readDataSource("omdb_film_by_id", map[string]tftypes.Value{"imdb_id": stringValue(id)})
+	}
+
+	// figures are totals across both keys
+	quota := h.readDataSource("omdb_quota", nil)
+	for name, expected := range map[string]int64{"used": 3, "budget": 10, "remaining": 7} {
+		var n big.Float
+		err := quota[name].As(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := n.Int64(); v != expected {
+			t.Fatalf("expected %s %d, got %s", name, expected, n.String())
+		}
+	}
+
+	var resetsAt string
+	err := quota["resets_at"].As(&resetsAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resets, err := time.Parse(time.RFC3339, resetsAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resets.Equal(resets.Truncate(24*time.Hour)) || !resets.After(time.Now()) || resets.After(time.Now().Add(24*time.Hour)) {
+		t.Fatalf("expected resets_at to be the next UTC midnight, got %q", resetsAt)
+	}
+}
+
+func TestDataSourceQuotaReadWhileReplaying(t *testing.T) {
+	t.Setenv(envCassetteMode, cassetteModeReplay)
+	t.Setenv(envCassetteDir, t.TempDir())
+
+	h := newProtocolHarness(t)
+	h.configureLocal(t.TempDir())
+
+	// replayed responses don't count against the budget, so none are tracked
+	_, diags := h.readDataSourceDiagnostics(context.Background(), "omdb_quota", nil)
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError || diags[0].Summary != "quota tracking unavailable" {
+		t.Fatalf("expected a quota tracking unavailable error, got %v", diags)
+	}
+}
+
+func TestDataSourceCsvFilmsRead(t *testing.T) {
+	csvFile := filepath.Join(t.TempDir(), "diary.csv")
+	err := os.WriteFile(csvFile, []byte("Date,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n"+
+		"2022-01-03,Brazil,1985,https://boxd.it/a,4.5,,,2022-01-02\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newProtocolHarness(t)
+	h.configureLocal(t.TempDir())
+
+	state := h.readDataSource("omdb_csv_films", map[string]tftypes.Value{"path": stringValue(csvFile)})
+
+	var format string
+	err = state["format"].As(&format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "letterboxd" {
+		t.Fatalf("expected format letterboxd, got %q", format)
+	}
+
+	var films []tftypes.Value
+	err = state["films"].As(&films)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 1 {
+		t.Fatalf("expected 1 film, got %d", len(films))
+	}
+	film := h.attributes(films[0])
+	if !film["imdb_id"].IsNull() || h.stringAttribute(films[0], "watched_date") != "2022-01-02" {
+		t.Fatalf("unexpected film %v", film)
+	}
+	var rating big.Float
+	err = film["user_rating"].As(&rating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rating.String() != "9" {
+		t.Fatalf("expected user_rating 9, got %s", rating.String())
+	}
+}
+
+func TestResourceUserRatingLifecycle(t *testing.T) {
+	localDir := t.TempDir()
+	library := NewLibrary(localDir)
+	film := &Film{Title: "Brazil", Year: "1985"}
+	err := library.Create(film)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	const typeName = "omdb_user_rating"
+	schema := h.schemas.ResourceSchemas[typeName]
+	none := tftypes.NewValue(schema.ValueType(), nil)
+	score := func(i int64) tftypes.Value { return tftypes.NewValue(tftypes.Number, i) }
+
+	// create, with the date defaulted
+	alice := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"film_id":  stringValue(film.Id),
+		"reviewer": stringValue("alice"),
+		"score":    score(8),
+	})
+	if h.stringAttribute(alice, "id") != film.Id+"/alice" {
+		t.Fatalf("unexpected id %q", h.stringAttribute(alice, "id"))
+	}
+	date := h.stringAttribute(alice, "date")
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		t.Fatalf("expected a default date, got %q", date)
+	}
+
+	bob := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"film_id":  stringValue(film.Id),
+		"reviewer": stringValue("bob"),
+		"score":    score(6),
+		"review":   stringValue("Too long"),
+		"date":     stringValue("2022-01-01"),
+	})
+
+	// update in place keeps the date
+	alice = h.applyResource(typeName, alice, map[string]tftypes.Value{
+		"film_id":  stringValue(film.Id),
+		"reviewer": stringValue("alice"),
+		"score":    score(9),
+	})
+	if h.stringAttribute(h.readResource(typeName, alice), "date") != date {
+		t.Fatalf("expected date %q to survive update", date)
+	}
+
+	// updating the film keeps its user ratings
+	filmState := h.importResource("omdb_film", film.Id)
+	h.applyResource("omdb_film", filmState, map[string]tftypes.Value{
+		"title": stringValue("Brazil"),
+		"year":  stringValue("1986"),
+	})
+	updated, err := library.Read(film.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UserRating{
+		{Reviewer: "alice", Score: 9, Date: date},
+		{Reviewer: "bob", Score: 6, Review: "Too long", Date: "2022-01-01"},
+	}
+	if updated.Year != "1986" || !reflect.DeepEqual(updated.UserRatings, expected) {
+		t.Fatalf("unexpected film after update: %+v", updated)
+	}
+
+	// import
+	imported := h.importResource(typeName, film.Id+"/bob")
+	if !imported.Equal(bob) {
+		t.Fatalf("imported state %v differs from state %v", imported, bob)
+	}
+
+	// delete removes only alice's rating
+	h.applyResource(typeName, alice, nil)
+	updated, err = library.Read(film.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(updated.UserRatings, expected[1:]) {
+		t.Fatalf("unexpected user ratings after delete: %+v", updated.UserRatings)
+	}
+	if !h.readResource(typeName, alice).IsNull() {
+		t.Fatal("expected a deleted user rating to be removed from state")
+	}
+
+	// an empty review would be read back as null
+	diags := h.validateResource(typeName, map[string]tftypes.Value{
+		"film_id":  stringValue(film.Id),
+		"reviewer": stringValue("carol"),
+		"score":    score(5),
+		"review":   stringValue(""),
+	})
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError {
+		t.Fatalf("expected an error about the empty review, got %v", diags)
+	}
+
+	// destroying the film takes its ratings with it, with a warning
+	err = library.Remove(film.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, diags := h.readResourceDiagnostics(typeName, bob)
+	if !state.IsNull() {
+		t.Fatal("expected a user rating of a removed film to be removed from state")
+	}
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityWarning {
+		t.Fatalf("expected a warning about the removed film, got %v", diags)
+	}
+}
+
+func TestResourceCollectionLifecycle(t *testing.T) {
+	localDir := t.TempDir()
+	library := NewLibrary(localDir)
+	var filmIds []tftypes.Value
+	for _, title := range []string{"Brazil", "Alien"} {
+		film := &Film{Title: title, Year: "1985"}
+		err := library.Create(film)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filmIds = append(filmIds, stringValue(film.Id))
+	}
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	const typeName = "omdb_collection"
+	schema := h.schemas.ResourceSchemas[typeName]
+	none := tftypes.NewValue(schema.ValueType(), nil)
+	filmIdList := func(ids ...tftypes.Value) tftypes.Value {
+		return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, ids)
+	}
+
+	// create
+	state := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"name":     stringValue("Dystopias"),
+		"film_ids": filmIdList(filmIds...),
+	})
+	id := h.stringAttribute(state, "id")
+	if _, err := os.Stat(filepath.Join(localDir, collectionsDir, id)); err != nil {
+		t.Fatalf("expected a collection file after create: %s", err)
+	}
+	films, skipped, err := library.Films()
+	if err != nil || len(skipped) != 0 || len(films) != 2 {
+		t.Fatalf("expected the collection file not to be taken for a film, got %v, %v, %v", films, skipped, err)
+	}
+
+	// update in place, reordering
+	state = h.applyResource(typeName, state, map[string]tftypes.Value{
+		"name":        stringValue("Dystopias"),
+		"description": stringValue("Bleak futures"),
+		"film_ids":    filmIdList(filmIds[1], filmIds[0]),
+	})
+	state = h.readResource(typeName, state)
+	if !h.attributes(state)["film_ids"].Equal(filmIdList(filmIds[1], filmIds[0])) {
+		t.Fatalf("unexpected film_ids after update: %v", h.attributes(state)["film_ids"])
+	}
+
+	// import
+	imported := h.importResource(typeName, id)
+	if !imported.Equal(state) {
+		t.Fatalf("imported state %v differs from state %v", imported, state)
+	}
+
+	// a film which has gone is reported as a warning, at its index
+	var missingId string
+	err = filmIds[0].As(&missingId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = library.Remove(missingId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, diags := h.readResourceDiagnostics(typeName, state)
+	h.checkDiagnostics("ReadResource", diags)
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityWarning ||
+		!diags[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName("film_ids").WithElementKeyInt(1)) {
+		t.Fatalf("expected a warning about film_ids[1], got %v", diags)
+	}
+	if !read.Equal(state) {
+		t.Fatal("expected a missing film to be kept in state")
+	}
+
+	// and so is one which is missing when the collection is created
+	config := map[string]tftypes.Value{
+		"name":     stringValue("Lost"),
+		"film_ids": filmIdList(filmIds[1], filmIds[0]),
+	}
+	applyResp, err := h.server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   h.dynamicValue(schema, none),
+		PlannedState: h.dynamicValue(schema, h.planResource(typeName, none, config)),
+		Config:       h.dynamicValue(schema, h.object(schema, config)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.checkDiagnostics("ApplyResourceChange", applyResp.Diagnostics)
+	if len(applyResp.Diagnostics) != 1 || applyResp.Diagnostics[0].Severity != tfprotov6.DiagnosticSeverityWarning ||
+		!applyResp.Diagnostics[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName("film_ids").WithElementKeyInt(1)) {
+		t.Fatalf("expected a warning about film_ids[1], got %v", applyResp.Diagnostics)
+	}
+
+	// delete
+	state = h.applyResource(typeName, state, nil)
+	if !state.IsNull() {
+		t.Fatalf("expected null state after delete, got %v", state)
+	}
+	if !h.readResource(typeName, h.object(schema, map[string]tftypes.Value{"id": stringValue(id)})).IsNull() {
+		t.Fatal("expected a missing collection file to remove the resource from state")
+	}
+}
+
+func TestResourceViewingLifecycle(t *testing.T) {
+	localDir := t.TempDir()
+	film := &Film{Title: "Brazil", Year: "1985"}
+	err := NewLibrary(localDir).Create(film)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	const typeName = "omdb_viewing"
+	schema := h.schemas.ResourceSchemas[typeName]
+	none := tftypes.NewValue(schema.ValueType(), nil)
+	attendees := tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+		stringValue("Ann"),
+		stringValue("Bo"),
+	})
+
+	// create
+	first := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"film_id":   stringValue(film.Id),
+		"date":      stringValue("2022-03-01"),
+		"location":  stringValue("Hall"),
+		"attendees": attendees,
+	})
+	second := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"film_id": stringValue(film.Id),
+		"date":    stringValue("2022-05-01"),
+	})
+	h.applyResource(typeName, none, map[string]tftypes.Value{
+		"imdb_id": stringValue("tt0088846"),
+		"date":    stringValue("2022-04-01"),
+	})
+
+	// films must be in the library
+	resp, err := h.server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:   typeName,
+		PriorState: h.dynamicValue(schema, none),
+		PlannedState: h.dynamicValue(schema, h.object(schema, map[string]tftypes.Value{
+			"id":      tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"film_id": stringValue("missing"),
+			"date":    stringValue("2022-03-01"),
+		})),
+		Config: h.dynamicValue(schema, h.object(schema, map[string]tftypes.Value{
+			"film_id": stringValue("missing"),
+			"date":    stringValue("2022-03-01"),
+		})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Severity != tfprotov6.DiagnosticSeverityError {
+		t.Fatalf("expected an error about the missing film, got %v", resp.Diagnostics)
+	}
+
+	// an empty location would be read back as null
+	diags := h.validateResource(typeName, map[string]tftypes.Value{
+		"film_id":  stringValue(film.Id),
+		"date":     stringValue("2022-03-01"),
+		"location": stringValue(""),
+	})
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityError {
+		t.Fatalf("expected an error about the empty location, got %v", diags)
+	}
+
+	// update in place
+	first = h.applyResource(typeName, first, map[string]tftypes.Value{
+		"film_id":   stringValue(film.Id),
+		"date":      stringValue("2022-03-01"),
+		"location":  stringValue("Annex"),
+		"attendees": attendees,
+	})
+	if state := h.readResource(typeName, first); !state.Equal(first) {
+		t.Fatalf("read state %v differs from state %v", state, first)
+	}
+
+	// import
+	imported := h.importResource(typeName, h.stringAttribute(first, "id"))
+	if !imported.Equal(first) {
+		t.Fatalf("imported state %v differs from state %v", imported, first)
+	}
+
+	// delete
+	h.applyResource(typeName, second, nil)
+	if !h.readResource(typeName, second).IsNull() {
+		t.Fatal("expected a removed viewing to be removed from state")
+	}
+
+	// totals
+	viewings := h.readDataSource("omdb_viewings", nil)
+	var total big.Float
+	err = viewings["total"].As(&total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.String() != "2" {
+		t.Fatalf("expected 2 viewings, got %s", total.String())
+	}
+	var films map[string]tftypes.Value
+	err = viewings["films"].As(&films)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(films) != 2 || h.stringAttribute(films[film.Id], "last_watched") != "2022-03-01" ||
+		h.stringAttribute(films["tt0088846"], "imdb_id") != "tt0088846" {
+		t.Fatalf("unexpected films %v", films)
+	}
+}
+
+func TestResourceFilmTagsAndLabels(t *testing.T) {
+	localDir := t.TempDir()
+
+	h := newProtocolHarness(t)
+	h.configureLocal(localDir)
+
+	const typeName = "omdb_film"
+	schema := h.schemas.ResourceSchemas[typeName]
+	none := tftypes.NewValue(schema.ValueType(), nil)
+	tags := func(tags ...string) tftypes.Value {
+		values := make([]tftypes.Value, len(tags))
+		for i, tag := range tags {
+			values[i] = stringValue(tag)
+		}
+		return tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, values)
+	}
+	labels := func(labels map[string]string) tftypes.Value {
+		values := make(map[string]tftypes.Value, len(labels))
+		for k, v := range labels {
+			values[k] = stringValue(v)
+		}
+		return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, values)
+	}
+
+	brazil := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"title":  stringValue("Brazil"),
+		"year":   stringValue("1985"),
+		"tags":   tags("satire", "dystopia"),
+		"labels": labels(map[string]string{"festival": "2026", "mood": "bleak"}),
+	})
+	if state := h.readResource(typeName, brazil); !state.Equal(brazil) {
+		t.Fatalf("read state %v differs from state %v", state, brazil)
+	}
+	h.applyResource(typeName, none, map[string]tftypes.Value{
+		"title":  stringValue("Alien"),
+		"year":   stringValue("1979"),
+		"tags":   tags("horror"),
+		"labels": labels(map[string]string{"festival": "2026"}),
+	})
+
+	// empty tags and labels are read back empty, not null
+	ran := h.applyResource(typeName, none, map[string]tftypes.Value{
+		"title":  stringValue("Ran"),
+		"year":   stringValue("1985"),
+		"tags":   tags(),
+		"labels": labels(nil),
+	})
+	if state := h.readResource(typeName, ran); !state.Equal(ran) {
+		t.Fatalf("read state %v differs from state %v", state, ran)
+	}
+
+	for _, tc := range []struct {
+		filter map[string]tftypes.Value
+		titles []string
+	}{
+		{filter: nil, titles: []string{"Alien", "Brazil", "Ran"}},
+		{filter: map[string]tftypes.Value{"labels": labels(map[string]string{"festival": "2026"})}, titles: []string{"Alien", "Brazil"}},
+		{filter: map[string]tftypes.Value{"tags": tags("dystopia")}, titles: []string{"Brazil"}},
+		{filter: map[string]tftypes.Value{"tags": tags("dystopia", "horror")}, titles: nil},
+		{filter: map[string]tftypes.Value{"labels": labels(map[string]string{"festival": "2025"})}, titles: nil},
+	} {
+		listing := h.readDataSource("omdb_library_films", tc.filter)
+
+		var ids []tftypes.Value
+		err := listing["ids"].As(&ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var films map[string]tftypes.Value
+		err = listing["films"].As(&films)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var titles []string
+		for _, id := range ids {
+			var s string
+			err = id.As(&s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles = append(titles, h.stringAttribute(films[s], "title"))
+		}
+		if len(films) != len(ids) || !reflect.DeepEqual(titles, tc.titles) {
+			t.Fatalf("filter %v: expected %v, got %v", tc.filter, tc.titles, titles)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package omdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// quotaFileName is the file in local_dir where request counts are kept
+	quotaFileName = ".omdb_quota.json"
+
+	// quotaLockFileName is locked while the quota file is read and replaced,
+	// so that provider processes sharing local_dir don't lose each other's
+	// counts. The quota file itself can't be locked because it is replaced.
+	quotaLockFileName = ".omdb_quota.lock"
+
+	// quotaDayFormat renders the UTC day to which request counts belong
+	quotaDayFormat = "2006-01-02"
+)
+
+var errBudgetExhausted = errors.New("daily request budget exhausted")
+
+// quotaFileData defines what we expect to find in the quota file. API keys
+// are never written to disk; counts are keyed by a hash of the key.
+type quotaFileData struct {
+	Keys map[string]quotaKeyData `json:"keys"`
+}
+
+type quotaKeyData struct {
+	Day   string `json:"day"`
+	Count int64  `json:"count"`
+}
+
+// quotaUsage describes requests made with one API key on the current UTC day
+type quotaUsage struct {
+	used     int64
+	budget   int64 // zero when no budget is configured
+	resetsAt time.Time
+}
+
+// remaining returns the number of requests left in the budget, and false
+// when no budget is configured.
+func (u quotaUsage) remaining() (int64, bool) {
+	if u.budget <= 0 {
+		return 0, false
+	}
+	if u.used >= u.budget {
+		return 0, true
+	}
+	return u.budget - u.used, true
+}
+
+// quotaTracker counts OMDb API requests per API key per UTC day in a small
+// state file so that usage survives across provider processes, and
+// optionally refuses requests once a daily budget has been spent.
+type quotaTracker struct {
+	fileName     string
+	lockFileName string
+	budget       int64 // zero means unlimited
+	now          func() time.Time
+
+	lock sync.Mutex
+}
+
+func newQuotaTracker(localDir string, budget int64) *quotaTracker {
+	return &quotaTracker{
+		fileName:     filepath.Join(localDir, quotaFileName),
+		lockFileName: filepath.Join(localDir, quotaLockFileName),
+		budget:       budget,
+		now:          time.Now,
+	}
+}
+
+// quotaKeyId identifies an API key in the quota file without revealing it
+func quotaKeyId(apiKey string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(apiKey)))[:16]
+}
+
+// usage reports the requests made today with apiKey
+func (q *quotaTracker) usage(ctx context.Context, apiKey string) (quotaUsage, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	unlock, err := lockFile(q.lockFileName)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+	defer unlock()
+
+	data, err := q.read(ctx)
+	if err != nil {
+		return quotaUsage{}, err
+	}
+
+	return q.usageFrom(data, apiKey), nil
+}
+
+// spend records a request made with apiKey. It returns an error wrapping
+// errBudgetExhausted (and records nothing) when the budget is spent.
+func (q *quotaTracker) spend(ctx context.Context, apiKey string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	unlock, err := lockFile(q.lockFileName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := q.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage := q.usageFrom(data, apiKey)
+	if remaining, ok := usage.remaining(); ok && remaining == 0 {
+		return fmt.Errorf("%w - %d of %d requests used, the budget resets at %s",
+			errBudgetExhausted, usage.used, usage.budget, usage.resetsAt.Format(time.RFC3339))
+	}
+
+	data.Keys[quotaKeyId(apiKey)] = quotaKeyData{
+		Day:   q.today(),
+		Count: usage.used + 1,
+	}
+
+	return q.write(data)
+}
+
+// refund takes back a request recorded by spend which OMDb didn't count,
+// because it rejected the API key.
+func (q *quotaTracker) refund(ctx context.Context, apiKey string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	unlock, err := lockFile(q.lockFileName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := q.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage := q.usageFrom(data, apiKey)
+	if usage.used == 0 {
+		return nil
+	}
+	data.Keys[quotaKeyId(apiKey)] = quotaKeyData{
+		Day:   q.today(),
+		Count: usage.used - 1,
+	}
+
+	return q.write(data)
+}
+
+func (q *quotaTracker) today() string {
+	return q.now().UTC().Format(quotaDayFormat)
+}
+
+func (q *quotaTracker) usageFrom(data *quotaFileData, apiKey string) quotaUsage {
+	now := q.now().UTC()
+	usage := quotaUsage{
+		budget:   q.budget,
+		resetsAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// counts from previous days don't matter
+	if keyData, ok := data.Keys[quotaKeyId(apiKey)]; ok && keyData.Day == q.today() {
+		usage.used = keyData.Count
+	}
+
+	return usage
+}
+
+// read returns the content of the quota file. A file which can't be parsed
+// is treated as empty, with a warning, rather than failing every request;
+// the next write replaces it.
+func (q *quotaTracker) read(ctx context.Context) (*quotaFileData, error) {
+	data := &quotaFileData{Keys: make(map[string]quotaKeyData)}
+
+	b, err := os.ReadFile(q.fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("error reading quota file - %w", err)
+	}
+
+	err = json.Unmarshal(b, data)
+	if err != nil {
+		tflog.SubsystemWarn(ctx, logSubsystemApi, "ignoring unparsable quota file, request counts start again from zero", map[string]interface{}{
+			"file":  q.fileName,
+			"error": err.Error(),
+		})
+		return &quotaFileData{Keys: make(map[string]quotaKeyData)}, nil
+	}
+	if data.Keys == nil {
+		data.Keys = make(map[string]quotaKeyData)
+	}
+
+	return data, nil
+}
+
+// write replaces the quota file atomically, so that concurrent provider
+// processes never see a partially written file.
+func (q *quotaTracker) write(data *quotaFileData) error {
+	err := writeJsonFile(q.fileName, data)
+	if err != nil {
+		return fmt.Errorf("error writing quota file - %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package omdb
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on fileName, creating it as needed, and
+// returns a function which releases the lock. It blocks until the lock is
+// available.
+func lockFile(fileName string) (func(), error) {
+	f, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file - %w", err)
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("error locking %q - %w", fileName, err)
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,8 @@
+package omdb
+
+// lockFile is a no-op on Windows: request counts are kept consistent within
+// a provider process, but concurrent provider processes sharing local_dir
+// may lose each other's counts.
+func lockFile(string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,124 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaTrackerBudget(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)
+	q := newQuotaTracker(dir, 2)
+	q.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := q.spend(context.Background(), "key"); err != nil {
+			t.Fatalf("spend %d: %s", i, err)
+		}
+	}
+
+	err := q.spend(context.Background(), "key")
+	if !errors.Is(err, errBudgetExhausted) {
+		t.Fatalf("expected errBudgetExhausted, got %v", err)
+	}
+
+	// other keys have their own budget
+	if err := q.spend(context.Background(), "other key"); err != nil {
+		t.Fatalf("spend with other key: %s", err)
+	}
+
+	usage, err := q.usage(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.used != 2 {
+		t.Fatalf("expected 2 requests used, got %d", usage.used)
+	}
+	if remaining, ok := usage.remaining(); !ok || remaining != 0 {
+		t.Fatalf("expected 0 remaining, got %d (%t)", remaining, ok)
+	}
+	if !usage.resetsAt.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected reset time %s", usage.resetsAt)
+	}
+
+	// a new UTC day resets the count
+	now = now.Add(2 * time.Minute)
+	if err := q.spend(context.Background(), "key"); err != nil {
+		t.Fatalf("spend on new day: %s", err)
+	}
+	usage, err = q.usage(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.used != 1 {
+		t.Fatalf("expected 1 request used on new day, got %d", usage.used)
+	}
+}
+
+func TestQuotaTrackerDoesNotStoreKey(t *testing.T) {
+	dir := t.TempDir()
+	q := newQuotaTracker(dir, 0)
+
+	if err := q.spend(context.Background(), testSecretApiKey); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, quotaFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), testSecretApiKey) {
+		t.Fatalf("quota file contains the API key: %s", b)
+	}
+
+	usage, err := q.usage(context.Background(), testSecretApiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := usage.remaining(); ok {
+		t.Fatal("expected no budget")
+	}
+}
+
+func TestQuotaTrackerSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	q := newQuotaTracker(dir, 2)
+	if err := q.spend(context.Background(), "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new provider process picks up where the last one left off
+	q = newQuotaTracker(dir, 2)
+	if err := q.spend(context.Background(), "key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.spend(context.Background(), "key"); !errors.Is(err, errBudgetExhausted) {
+		t.Fatalf("expected errBudgetExhausted after restart, got %v", err)
+	}
+}
+
+func TestQuotaTrackerIgnoresCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, quotaFileName), []byte(`{"keys":`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := newQuotaTracker(dir, 2)
+	if err := q.spend(context.Background(), "key"); err != nil {
+		t.Fatalf("expected a corrupt quota file to be treated as empty, got %s", err)
+	}
+	usage, err := q.usage(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.used != 1 {
+		t.Fatalf("expected 1 request used, got %d", usage.used)
+	}
+}
@@ -0,0 +1,122 @@
+package omdb
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"net/url"
+	"strings"
+)
+
+// redactedPlaceholder replaces secrets in redacted strings
+const redactedPlaceholder = "***"
+
+// redactor masks secrets (API keys) in errors, diagnostics and logs. Both the
+// raw and URL-encoded forms of each secret are masked, because the API key
+// appears URL-encoded in request URLs, which Go's url.Error includes in its
+// message.
+type redactor struct {
+	secrets []string
+}
+
+func newRedactor(secrets ...string) *redactor {
+	r := &redactor{}
+	for _, secret := range secrets {
+		r.add(secret)
+	}
+	return r
+}
+
+// add registers a secret with the redactor
+func (r *redactor) add(secret string) {
+	if secret == "" {
+		return
+	}
+	for _, s := range []string{secret, url.QueryEscape(secret), url.PathEscape(secret)} {
+		if !r.has(s) {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+}
+
+func (r *redactor) has(secret string) bool {
+	for _, s := range r.secrets {
+		if s == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns s with every secret masked
+func (r *redactor) String(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedPlaceholder)
+	}
+	return s
+}
+
+// Error returns an error whose message has every secret masked. The
+// original error remains available to errors.Is() and errors.As().
+func (r *redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{msg: r.String(err.Error()), err: err}
+}
+
+// Diagnostics returns a copy of diags with every secret masked in each
+// diagnostic's summary and detail.
+func (r *redactor) Diagnostics(diags diag.Diagnostics) diag.Diagnostics {
+	if diags == nil {
+		return nil
+	}
+
+	result := make(diag.Diagnostics, len(diags))
+	for i, d := range diags {
+		summary := r.String(d.Summary())
+		detail := r.String(d.Detail())
+
+		var redacted diag.Diagnostic
+		switch d.Severity() {
+		case diag.SeverityWarning:
+			redacted = diag.NewWarningDiagnostic(summary, detail)
+		default:
+			redacted = diag.NewErrorDiagnostic(summary, detail)
+		}
+
+		if withPath, ok := d.(diag.DiagnosticWithPath); ok {
+			redacted = diag.WithPath(withPath.Path(), redacted)
+		}
+
+		result[i] = redacted
+	}
+	return result
+}
+
+// Context returns a context whose tflog output, from the root logger and
+// from every provider subsystem, has every secret masked
+func (r *redactor) Context(ctx context.Context) context.Context {
+	if len(r.secrets) == 0 {
+		return ctx
+	}
+	ctx = tflog.MaskLogStrings(ctx, r.secrets...)
+	for _, subsystem := range logSubsystems {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, r.secrets...)
+	}
+	return ctx
+}
+
+// redactedError is an error with secrets masked from its message
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
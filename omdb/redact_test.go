@@ -0,0 +1,173 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testSecretApiKey = "s3cr3t+k3y/with&specials"
+
+func TestRedactorString(t *testing.T) {
+	r := newRedactor(testSecretApiKey)
+
+	testCases := map[string]string{
+		"raw":           "key is " + testSecretApiKey,
+		"query_escaped": "http://x/?apikey=" + url.QueryEscape(testSecretApiKey),
+		"path_escaped":  "http://x/" + url.PathEscape(testSecretApiKey),
+	}
+
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			out := r.String(in)
+			assertNoSecret(t, out)
+			if !strings.Contains(out, redactedPlaceholder) {
+				t.Fatalf("expected %q to contain %q", out, redactedPlaceholder)
+			}
+		})
+	}
+}
+
+func TestRedactorError(t *testing.T) {
+	r := newRedactor(testSecretApiKey)
+	inner := errors.New("boom " + testSecretApiKey)
+
+	err := r.Error(inner)
+	assertNoSecret(t, err.Error())
+	if !errors.Is(err, inner) {
+		t.Fatal("redacted error does not wrap the original error")
+	}
+	if r.Error(nil) != nil {
+		t.Fatal("redacting a nil error should produce nil")
+	}
+}
+
+func TestRedactorDiagnostics(t *testing.T) {
+	r := newRedactor(testSecretApiKey)
+
+	var diags diag.Diagnostics
+	diags.AddError("error "+testSecretApiKey, "detail "+testSecretApiKey)
+	diags.AddWarning("warning "+testSecretApiKey, "detail "+testSecretApiKey)
+	diags.AddAttributeError(path.Root("imdb_ids"), "attr error", testSecretApiKey)
+
+	redacted := r.Diagnostics(diags)
+	if len(redacted) != len(diags) {
+		t.Fatalf("expected %d diagnostics, got %d", len(diags), len(redacted))
+	}
+	assertDiagnosticsNoSecret(t, redacted)
+
+	if redacted[1].Severity() != diag.SeverityWarning {
+		t.Fatalf("expected warning severity to survive redaction, got %s", redacted[1].Severity())
+	}
+	if withPath, ok := redacted[2].(diag.DiagnosticWithPath); !ok || !withPath.Path().Equal(path.Root("imdb_ids")) {
+		t.Fatal("expected attribute path to survive redaction")
+	}
+}
+
+// TestDataSourceReadRedactsApiKey drives the data sources against an
+// unreachable endpoint and against an endpoint which echoes the request URL
+// back in an error, and checks that the API key never appears in the
+// resulting diagnostics.
+func TestDataSourceReadRedactsApiKey(t *testing.T) {
+	// an address nothing is listening on, so the request fails with a
+	// *url.Error which includes the full request URL
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable := "http://" + listener.Addr().String()
+	_ = listener.Close()
+
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Response":"False","Error":"bad request ` + r.URL.String() + `"}`))
+	}))
+	defer echo.Close()
+
+	endpoints := map[string]string{
+		"unreachable": unreachable,
+		"echo":        echo.URL,
+	}
+
+	for endpointName, endpoint := range endpoints {
+		dataSources := map[string]datasource.DataSource{
+			"film_by_id":   &DataSourceFilmById{client: newClient(endpoint, []string{testSecretApiKey})},
+			"films_by_ids": &DataSourceFilmsByIds{client: newClient(endpoint, []string{testSecretApiKey})},
+		}
+		configs := map[string]map[string]tftypes.Value{
+			"film_by_id": {"imdb_id": tftypes.NewValue(tftypes.String, "tt0080455")},
+			"films_by_ids": {
+				"imdb_ids":      tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "tt0080455")}),
+				"fail_on_error": tftypes.NewValue(tftypes.Bool, true),
+			},
+		}
+
+		for dsName, ds := range dataSources {
+			t.Run(endpointName+"_"+dsName, func(t *testing.T) {
+				diags := readDataSource(t, ds, configs[dsName])
+				if !diags.HasError() {
+					t.Fatal("expected an error diagnostic")
+				}
+				assertDiagnosticsNoSecret(t, diags)
+			})
+		}
+	}
+}
+
+// readDataSource invokes ds.Read() with a configuration made up of the
+// supplied attribute values (all others null) and returns the diagnostics.
+func readDataSource(t *testing.T, ds datasource.DataSource, attrs map[string]tftypes.Value) diag.Diagnostics {
+	t.Helper()
+	ctx := context.Background()
+
+	schema, diags := ds.GetSchema(ctx)
+	if diags.HasError() {
+		t.Fatalf("schema error: %v", diags)
+	}
+
+	objectType := schema.Type().TerraformType(ctx).(tftypes.Object)
+	vals := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		if v, ok := attrs[name]; ok {
+			vals[name] = v
+		} else {
+			vals[name] = tftypes.NewValue(attrType, nil)
+		}
+	}
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{Schema: schema, Raw: tftypes.NewValue(objectType, vals)},
+	}
+	resp := datasource.ReadResponse{
+		State: tfsdk.State{Schema: schema, Raw: tftypes.NewValue(objectType, nil)},
+	}
+	ds.Read(ctx, req, &resp)
+
+	return resp.Diagnostics
+}
+
+func assertNoSecret(t *testing.T, s string) {
+	t.Helper()
+	for _, secret := range []string{testSecretApiKey, url.QueryEscape(testSecretApiKey), url.PathEscape(testSecretApiKey)} {
+		if strings.Contains(s, secret) {
+			t.Fatalf("API key leaked: %q", s)
+		}
+	}
+}
+
+func assertDiagnosticsNoSecret(t *testing.T, diags diag.Diagnostics) {
+	t.Helper()
+	for _, d := range diags {
+		assertNoSecret(t, d.Summary())
+		assertNoSecret(t, d.Detail())
+	}
+}
@@ -0,0 +1,264 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// collectionData is a terraform config/plan/state style object
+type collectionData struct {
+	Id          types.String `tfsdk:"id"`
+	Name        types.String `tfsdk:"name"`
+	Description types.String `tfsdk:"description"`
+	FilmIds     []string     `tfsdk:"film_ids"`
+}
+
+// collection returns the collection described by a plan
+func (o collectionData) collection() *Collection {
+	return &Collection{
+		Id:          o.Id.Value,
+		Name:        o.Name.Value,
+		Description: o.Description.Value,
+		FilmIds:     append([]string{}, o.FilmIds...),
+	}
+}
+
+var _ resource.Resource = &ResourceCollection{}
+var _ resource.ResourceWithConfigure = &ResourceCollection{}
+var _ resource.ResourceWithImportState = &ResourceCollection{}
+
+// ResourceCollection implements the resource.ResourceWithConfigure interface
+type ResourceCollection struct {
+	library *Library
+}
+
+func (r *ResourceCollection) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_collection"
+}
+
+func (r *ResourceCollection) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerResourceData); ok {
+		r.library = NewLibrary(providerData.localDir)
+	} else {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (r *ResourceCollection) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Resource groups `omdb_film` resources into a named, ordered list, such as a " +
+			"watchlist or a themed season. It is kept in a file of its own in the `collections` directory of " +
+			"`local_dir`. Films which no longer exist are reported as warnings when the collection is read.",
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "Unique ID",
+				Computed:            true,
+				Type:                types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown(),
+				},
+			},
+			"name": {
+				MarkdownDescription: "Collection name",
+				Required:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"description": {
+				MarkdownDescription: "Collection description",
+				Optional:            true,
+				Type:                types.StringType,
+			},
+			"film_ids": {
+				MarkdownDescription: "IDs of the `omdb_film` resources in the collection, in order",
+				Required:            true,
+				Type:                types.ListType{ElemType: types.StringType},
+				Validators: []tfsdk.AttributeValidator{
+					listvalidator.ValuesAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (r *ResourceCollection) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var plan collectionData
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	collection := plan.collection()
+	r.checkFilms(collection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.library.CreateCollection(collection)
+	if err != nil {
+		resp.Diagnostics.AddError("error writing collection to file", err.Error())
+		return
+	}
+	plan.Id = types.String{Value: collection.Id}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "created collection file", map[string]interface{}{
+		"id":    collection.Id,
+		"dir":   r.library.Dir(),
+		"films": len(collection.FilmIds),
+	})
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceCollection) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state collectionData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	collection, err := r.library.ReadCollection(state.Id.Value)
+	if err != nil {
+		if isNotExist(err) {
+			tflog.SubsystemDebug(ctx, logSubsystemFiles, "collection file not found, removing collection from state", map[string]interface{}{
+				"id":  state.Id.Value,
+				"dir": r.library.Dir(),
+			})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("error reading/parsing file", err.Error())
+		return
+	}
+
+	missing := r.checkFilms(collection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "read collection file", map[string]interface{}{
+		"id":      collection.Id,
+		"dir":     r.library.Dir(),
+		"films":   len(collection.FilmIds),
+		"missing": len(missing),
+	})
+
+	newState := collectionData{
+		Id:          types.String{Value: state.Id.Value},
+		Name:        types.String{Value: collection.Name},
+		Description: types.String{Value: collection.Description, Null: collection.Description == ""},
+		FilmIds:     append([]string{}, collection.FilmIds...),
+	}
+
+	diags = resp.State.Set(ctx, &newState)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceCollection) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state collectionData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan collectionData
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Id = types.String{Value: state.Id.Value}
+
+	collection := plan.collection()
+	r.checkFilms(collection, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.library.WriteCollection(collection)
+	if err != nil {
+		resp.Diagnostics.AddError("error writing collection to file", err.Error())
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "wrote collection file", map[string]interface{}{
+		"id":    collection.Id,
+		"dir":   r.library.Dir(),
+		"films": len(collection.FilmIds),
+	})
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceCollection) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state collectionData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.library.RemoveCollection(state.Id.Value)
+	if err != nil && !isNotExist(err) {
+		resp.Diagnostics.AddError("delete error", err.Error())
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "removed collection file", map[string]interface{}{
+		"id":  state.Id.Value,
+		"dir": r.library.Dir(),
+	})
+}
+
+// checkFilms adds a warning to diags, at its index in film_ids, for each of
+// the collection's films which isn't in the library, and returns their IDs
+func (r *ResourceCollection) checkFilms(collection *Collection, diags *diag.Diagnostics) []string {
+	missing, err := r.library.MissingFilms(collection)
+	if err != nil {
+		diags.AddError("error checking collection films", err.Error())
+		return nil
+	}
+
+	isMissing := make(map[string]bool, len(missing))
+	for _, id := range missing {
+		isMissing[id] = true
+	}
+	for i, id := range collection.FilmIds {
+		if isMissing[id] {
+			diags.AddAttributeWarning(path.Root("film_ids").AtListIndex(i), "film not found",
+				fmt.Sprintf("Collection %q refers to film %q, which is not in %q. It may have been "+
+					"destroyed; remove it from film_ids.", collection.Name, id, r.library.Dir()))
+		}
+	}
+
+	return missing
+}
+
+// ImportState adopts an existing file in the collections directory of
+// localDir; the import ID is the file name. Read() fills in the rest.
+func (r *ResourceCollection) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
@@ -2,45 +2,74 @@ package omdb
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/schemavalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"io/ioutil"
-	"math/rand"
-	"os"
-	"path/filepath"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sort"
 )
 
-// filmFileData defines what we expect to find in the files in baseDir
-type filmFileData struct {
-	Title   string `json:"Title"`
-	Year    string `json:"Year"`
-	Ratings []struct {
-		Source string `json:"Source"`
-		Value  string `json:"Value"`
-	} `json:"Ratings,omitempty"`
-}
-
 // filmByIdData is a terraform config/plan/state style object
 type filmData struct {
-	Id       types.String     `tfsdk:"id"`
-	Title    types.String     `tfsdk:"title"`
-	Year     types.String     `tfsdk:"year"`
-	Ratings0 []filmRatingData `tfsdk:"ratings0"`
-	Ratings1 []types.Object   `tfsdk:"ratings1"`
-	Ratings2 types.List       `tfsdk:"ratings2"`
+	Id       types.String      `tfsdk:"id"`
+	Title    types.String      `tfsdk:"title"`
+	Year     types.String      `tfsdk:"year"`
+	Ratings0 []filmRatingData  `tfsdk:"ratings0"`
+	Ratings1 []types.Object    `tfsdk:"ratings1"`
+	Ratings2 types.List        `tfsdk:"ratings2"`
+	Tags     []string          `tfsdk:"tags"`
+	Labels   map[string]string `tfsdk:"labels"`
+}
+
+// film returns the film described by a plan. Ratings come from ratings0 or
+// ratings2 (which is what Read() populates, and so what configuration
+// generated from an existing library uses); the two conflict.
+func (o filmData) film(ctx context.Context) (*Film, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	ratings := o.Ratings0
+	if len(ratings) == 0 && !o.Ratings2.Null && !o.Ratings2.Unknown {
+		diags.Append(o.Ratings2.ElementsAs(ctx, &ratings, false)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+	}
+
+	film := &Film{
+		Id:      o.Id.Value,
+		Title:   o.Title.Value,
+		Year:    o.Year.Value,
+		Ratings: make([]Rating, len(ratings)),
+		Labels:  o.Labels,
+	}
+	if len(o.Tags) > 0 {
+		film.Tags = append([]string{}, o.Tags...)
+		sort.Strings(film.Tags)
+	}
+	for i, rating := range ratings {
+		film.Ratings[i] = Rating{
+			Source: rating.Source.Value,
+			Value:  rating.Value.Value,
+		}
+	}
+	return film, diags
 }
 
 var _ resource.Resource = &ResourceFilm{}
 var _ resource.ResourceWithConfigure = &ResourceFilm{}
+var _ resource.ResourceWithImportState = &ResourceFilm{}
 
 // ResourceFilm implements the datasource.DataSourceWithConfigure interface
 type ResourceFilm struct {
-	localDir string
+	library *Library
 }
 
 func (r *ResourceFilm) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -53,7 +82,7 @@ func (r *ResourceFilm) Configure(ctx context.Context, req resource.ConfigureRequ
 	}
 
 	if providerData, ok := req.ProviderData.(*providerResourceData); ok {
-		r.localDir = providerData.localDir
+		r.library = NewLibrary(providerData.localDir)
 	} else {
 		resp.Diagnostics.AddError("Unexpected Resource Configure Type",
 			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
@@ -116,8 +145,11 @@ func (r *ResourceFilm) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagno
 				}),
 			},
 			"ratings2": {
-				MarkdownDescription: "Ratings2",
+				MarkdownDescription: "Ratings2, conflicts with `ratings0`",
 				Optional:            true,
+				Validators: []tfsdk.AttributeValidator{
+					schemavalidator.ConflictsWith(path.MatchRoot("ratings0")),
+				},
 				Type: types.ListType{
 					ElemType: types.ObjectType{
 						AttrTypes: map[string]attr.Type{
@@ -127,11 +159,29 @@ func (r *ResourceFilm) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagno
 					},
 				},
 			},
+			"tags": {
+				MarkdownDescription: "Tags categorizing the film, e.g. `noir`",
+				Optional:            true,
+				Type:                types.SetType{ElemType: types.StringType},
+				Validators: []tfsdk.AttributeValidator{
+					setvalidator.ValuesAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"labels": {
+				MarkdownDescription: "Labels categorizing the film, e.g. `festival = \"2026\"`",
+				Optional:            true,
+				Type:                types.MapType{ElemType: types.StringType},
+				Validators: []tfsdk.AttributeValidator{
+					mapvalidator.KeysAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
 		},
 	}, diag.Diagnostics{}
 }
 
 func (r *ResourceFilm) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
 	var plan filmData
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -139,47 +189,29 @@ func (r *ResourceFilm) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	film := &filmFileData{
-		Title: plan.Title.Value,
-		Year:  plan.Year.Value,
-	}
-
-	film.Ratings = make([]struct {
-		Source string `json:"Source"`
-		Value  string `json:"Value"`
-	}, len(plan.Ratings0))
-
-	for i, rating := range plan.Ratings0 {
-		film.Ratings[i] = struct {
-			Source string `json:"Source"`
-			Value  string `json:"Value"`
-		}{
-			Source: rating.Source.Value,
-			Value:  rating.Value.Value,
-		}
-	}
-
-	data, err := json.MarshalIndent(film, "", "  ")
-	if err != nil {
-		resp.Diagnostics.AddError("error marshaling film to JSON", err.Error())
+	film, diags := plan.film(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	b := make([]byte, 8)
-	rand.Read(b)
-	plan.Id = types.String{Value: fmt.Sprintf("%x", b)}
-
-	err = ioutil.WriteFile(filepath.Join(r.localDir, plan.Id.Value), data, 0644)
+	err := r.library.Create(film)
 	if err != nil {
 		resp.Diagnostics.AddError("error writing film to file", err.Error())
 		return
 	}
+	plan.Id = types.String{Value: film.Id}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "created film file", map[string]interface{}{
+		"id":  film.Id,
+		"dir": r.library.Dir(),
+	})
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
 }
 
 func (r *ResourceFilm) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
 	var state filmData
 	diags := req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -187,21 +219,24 @@ func (r *ResourceFilm) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	file, err := os.Open(filepath.Join(r.localDir, state.Id.Value))
+	film, err := r.library.Read(state.Id.Value)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if isNotExist(err) {
+			tflog.SubsystemDebug(ctx, logSubsystemFiles, "film file not found, removing film from state", map[string]interface{}{
+				"id":  state.Id.Value,
+				"dir": r.library.Dir(),
+			})
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("error opening file", err.Error())
-	}
-	defer func(file *os.File) { _ = file.Close() }(file)
-
-	var film filmFileData
-	err = json.NewDecoder(file).Decode(&film)
-	if err != nil {
 		resp.Diagnostics.AddError("error reading/parsing file", err.Error())
+		return
 	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "read film file", map[string]interface{}{
+		"id":      film.Id,
+		"dir":     r.library.Dir(),
+		"ratings": len(film.Ratings),
+	})
 
 	newState := filmData{
 		Id:    types.String{Value: state.Id.Value},
@@ -233,6 +268,18 @@ func (r *ResourceFilm) Read(ctx context.Context, req resource.ReadRequest, resp
 		newState.Ratings2.Null = true
 	}
 
+	// an empty set or map is stored as nothing at all; keep it as the
+	// configuration wrote it rather than as null
+	if len(film.Tags) > 0 || state.Tags != nil {
+		newState.Tags = append([]string{}, film.Tags...)
+	}
+	if len(film.Labels) > 0 || state.Labels != nil {
+		newState.Labels = make(map[string]string, len(film.Labels))
+		for k, v := range film.Labels {
+			newState.Labels[k] = v
+		}
+	}
+
 	//o, _ := json.Marshal(state)
 	//n, _ := json.Marshal(newState)
 	//resp.Diagnostics.AddWarning("old", string(o))
@@ -243,6 +290,8 @@ func (r *ResourceFilm) Read(ctx context.Context, req resource.ReadRequest, resp
 }
 
 func (r *ResourceFilm) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
 	var state filmData
 	diags := req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -259,43 +308,37 @@ func (r *ResourceFilm) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	plan.Id = types.String{Value: state.Id.Value}
 
-	film := &filmFileData{
-		Title: plan.Title.Value,
-		Year:  plan.Year.Value,
-	}
-
-	film.Ratings = make([]struct {
-		Source string `json:"Source"`
-		Value  string `json:"Value"`
-	}, len(plan.Ratings0))
-
-	for i, rating := range plan.Ratings0 {
-		film.Ratings[i] = struct {
-			Source string `json:"Source"`
-			Value  string `json:"Value"`
-		}{
-			Source: rating.Source.Value,
-			Value:  rating.Value.Value,
-		}
-	}
-
-	data, err := json.MarshalIndent(film, "", "  ")
-	if err != nil {
-		resp.Diagnostics.AddError("error marshaling film to JSON", err.Error())
+	film, diags := plan.film(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	err = ioutil.WriteFile(filepath.Join(r.localDir, plan.Id.Value), data, 0644)
+	// the file also holds things this resource doesn't manage, such as
+	// omdb_user_rating entries, so change only what's planned
+	err := r.library.Update(film.Id, func(existing *Film) error {
+		existing.Title = film.Title
+		existing.Year = film.Year
+		existing.Ratings = film.Ratings
+		existing.Tags = film.Tags
+		existing.Labels = film.Labels
+		return nil
+	})
 	if err != nil {
 		resp.Diagnostics.AddError("error writing film to file", err.Error())
 		return
 	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "wrote film file", map[string]interface{}{
+		"id":  film.Id,
+		"dir": r.library.Dir(),
+	})
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
 }
 
 func (r *ResourceFilm) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
 	var state filmData
 	diags := req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -308,9 +351,19 @@ func (r *ResourceFilm) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	fileName := filepath.Join(r.localDir, state.Id.Value)
-	err := os.Remove(fileName)
+	err := r.library.Remove(state.Id.Value)
 	if err != nil {
 		resp.Diagnostics.AddError("delete error", err.Error())
+		return
 	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "removed film file", map[string]interface{}{
+		"id":  state.Id.Value,
+		"dir": r.library.Dir(),
+	})
+}
+
+// ImportState adopts an existing file in localDir; the import ID is the
+// file name. Read() fills in the rest.
+func (r *ResourceFilm) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
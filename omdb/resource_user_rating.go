@@ -0,0 +1,335 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"strings"
+	"time"
+)
+
+const (
+	minUserRatingScore = 1
+	maxUserRatingScore = 10
+
+	// userRatingIdSeparator joins film ID and reviewer in a user rating's
+	// ID. Film IDs can't contain it.
+	userRatingIdSeparator = "/"
+)
+
+// userRatingData is a terraform config/plan/state style object
+type userRatingData struct {
+	Id       types.String `tfsdk:"id"`
+	FilmId   types.String `tfsdk:"film_id"`
+	Reviewer types.String `tfsdk:"reviewer"`
+	Score    types.Int64  `tfsdk:"score"`
+	Review   types.String `tfsdk:"review"`
+	Date     types.String `tfsdk:"date"`
+}
+
+// userRating returns the film file entry described by a plan
+func (o userRatingData) userRating() UserRating {
+	return UserRating{
+		Reviewer: o.Reviewer.Value,
+		Score:    o.Score.Value,
+		Review:   o.Review.Value,
+		Date:     o.Date.Value,
+	}
+}
+
+// parseUserRatingId splits a user rating ID into film ID and reviewer
+func parseUserRatingId(id string) (string, string, error) {
+	filmId, reviewer, ok := strings.Cut(id, userRatingIdSeparator)
+	if !ok || filmId == "" || reviewer == "" {
+		return "", "", fmt.Errorf("invalid user rating ID %q, expected <film ID>%s<reviewer>", id, userRatingIdSeparator)
+	}
+	return filmId, reviewer, nil
+}
+
+// userRatingIndex returns the index of reviewer's rating in film.UserRatings,
+// or -1
+func userRatingIndex(film *Film, reviewer string) int {
+	for i, userRating := range film.UserRatings {
+		if userRating.Reviewer == reviewer {
+			return i
+		}
+	}
+	return -1
+}
+
+var _ resource.Resource = &ResourceUserRating{}
+var _ resource.ResourceWithConfigure = &ResourceUserRating{}
+var _ resource.ResourceWithImportState = &ResourceUserRating{}
+
+// ResourceUserRating implements the resource.ResourceWithConfigure interface
+type ResourceUserRating struct {
+	library *Library
+}
+
+func (r *ResourceUserRating) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_user_rating"
+}
+
+func (r *ResourceUserRating) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerResourceData); ok {
+		r.library = NewLibrary(providerData.localDir)
+	} else {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (r *ResourceUserRating) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Resource records a reviewer's own rating of an `omdb_film`, as opposed to the " +
+			"critics' ratings copied from OMDb. It is kept in the film's file in `local_dir`, alongside any " +
+			"other reviewers' ratings, and each reviewer may rate a film once. Destroying the `omdb_film` " +
+			"deletes its file and so its user ratings; refer to the film's `id` in `film_id` so that terraform " +
+			"destroys the ratings first.",
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "Unique ID: the film ID and reviewer, separated by `/`",
+				Computed:            true,
+				Type:                types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown(),
+				},
+			},
+			"film_id": {
+				MarkdownDescription: "ID of the `omdb_film` rated",
+				Required:            true,
+				Type:                types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.RequiresReplace(),
+				},
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"reviewer": {
+				MarkdownDescription: "Name of the reviewer",
+				Required:            true,
+				Type:                types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.RequiresReplace(),
+				},
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"score": {
+				MarkdownDescription: fmt.Sprintf("Score from %d to %d", minUserRatingScore, maxUserRatingScore),
+				Required:            true,
+				Type:                types.Int64Type,
+				Validators: []tfsdk.AttributeValidator{
+					int64validator.Between(minUserRatingScore, maxUserRatingScore),
+				},
+			},
+			"review": {
+				MarkdownDescription: "Review text",
+				Optional:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"date": {
+				MarkdownDescription: "Date of the rating as YYYY-MM-DD. Defaults to the (UTC) date it was created.",
+				Optional:            true,
+				Computed:            true,
+				Type:                types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown(),
+				},
+				Validators: []tfsdk.AttributeValidator{
+					dateValidator{},
+				},
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (r *ResourceUserRating) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var plan userRatingData
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Date.Unknown || plan.Date.Null {
+		plan.Date = types.String{Value: time.Now().UTC().Format(dateLayout)}
+	}
+
+	err := r.library.Update(plan.FilmId.Value, func(film *Film) error {
+		if userRatingIndex(film, plan.Reviewer.Value) >= 0 {
+			return fmt.Errorf("film %q already has a rating by %q, import it to manage it", film.Id, plan.Reviewer.Value)
+		}
+		film.UserRatings = append(film.UserRatings, plan.userRating())
+		return nil
+	})
+	if err != nil {
+		if isNotExist(err) {
+			resp.Diagnostics.AddAttributeError(path.Root("film_id"), "film not found",
+				fmt.Sprintf("no film %q in %q", plan.FilmId.Value, r.library.Dir()))
+			return
+		}
+		resp.Diagnostics.AddError("error writing user rating to film file", err.Error())
+		return
+	}
+	plan.Id = types.String{Value: plan.FilmId.Value + userRatingIdSeparator + plan.Reviewer.Value}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "added user rating to film file", map[string]interface{}{
+		"id":       plan.FilmId.Value,
+		"dir":      r.library.Dir(),
+		"reviewer": plan.Reviewer.Value,
+	})
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceUserRating) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state userRatingData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	film, err := r.library.Read(state.FilmId.Value)
+	if err != nil {
+		if isNotExist(err) {
+			tflog.SubsystemDebug(ctx, logSubsystemFiles, "film file not found, removing user rating from state", map[string]interface{}{
+				"id":  state.FilmId.Value,
+				"dir": r.library.Dir(),
+			})
+			// the rating went with the film's file, most likely when the
+			// omdb_film was destroyed
+			resp.Diagnostics.AddWarning("film not found",
+				fmt.Sprintf("user rating by %q is removed from state, because film %q is no longer in %q",
+					state.Reviewer.Value, state.FilmId.Value, r.library.Dir()))
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("error reading/parsing file", err.Error())
+		return
+	}
+
+	i := userRatingIndex(film, state.Reviewer.Value)
+	if i < 0 {
+		tflog.SubsystemDebug(ctx, logSubsystemFiles, "user rating not found, removing it from state", map[string]interface{}{
+			"id":       state.FilmId.Value,
+			"dir":      r.library.Dir(),
+			"reviewer": state.Reviewer.Value,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	userRating := film.UserRatings[i]
+
+	newState := userRatingData{
+		Id:       types.String{Value: state.FilmId.Value + userRatingIdSeparator + userRating.Reviewer},
+		FilmId:   types.String{Value: state.FilmId.Value},
+		Reviewer: types.String{Value: userRating.Reviewer},
+		Score:    types.Int64{Value: userRating.Score},
+		Review:   types.String{Value: userRating.Review, Null: userRating.Review == ""},
+		Date:     types.String{Value: userRating.Date},
+	}
+
+	diags = resp.State.Set(ctx, &newState)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceUserRating) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var plan userRatingData
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// film_id and reviewer require replacement, so this is the same entry
+	err := r.library.Update(plan.FilmId.Value, func(film *Film) error {
+		i := userRatingIndex(film, plan.Reviewer.Value)
+		if i < 0 {
+			film.UserRatings = append(film.UserRatings, plan.userRating())
+		} else {
+			film.UserRatings[i] = plan.userRating()
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("error writing user rating to film file", err.Error())
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "updated user rating in film file", map[string]interface{}{
+		"id":       plan.FilmId.Value,
+		"dir":      r.library.Dir(),
+		"reviewer": plan.Reviewer.Value,
+	})
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceUserRating) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state userRatingData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// remove only this reviewer's entry, leaving the film and other ratings;
+	// Update() leaves the file alone when there is no entry
+	err := r.library.Update(state.FilmId.Value, func(film *Film) error {
+		i := userRatingIndex(film, state.Reviewer.Value)
+		if i < 0 {
+			return nil
+		}
+		film.UserRatings = append(film.UserRatings[:i], film.UserRatings[i+1:]...)
+		return nil
+	})
+	if err != nil && !isNotExist(err) {
+		resp.Diagnostics.AddError("delete error", err.Error())
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "removed user rating from film file", map[string]interface{}{
+		"id":       state.FilmId.Value,
+		"dir":      r.library.Dir(),
+		"reviewer": state.Reviewer.Value,
+	})
+}
+
+// ImportState adopts an existing entry in a film file; the import ID is the
+// film ID and the reviewer separated by "/". Read() fills in the rest.
+func (r *ResourceUserRating) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	filmId, reviewer, err := parseUserRatingId(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("invalid import ID", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("film_id"), filmId)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("reviewer"), reviewer)...)
+}
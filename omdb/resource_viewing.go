@@ -0,0 +1,273 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/schemavalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// viewingData is a terraform config/plan/state style object
+type viewingData struct {
+	Id        types.String `tfsdk:"id"`
+	FilmId    types.String `tfsdk:"film_id"`
+	ImdbId    types.String `tfsdk:"imdb_id"`
+	Date      types.String `tfsdk:"date"`
+	Location  types.String `tfsdk:"location"`
+	Attendees []string     `tfsdk:"attendees"`
+}
+
+// checkFilm adds an error to diags unless film_id, when set, names a film in
+// library
+func (o viewingData) checkFilm(library *Library, diags *diag.Diagnostics) {
+	if o.FilmId.Null || o.FilmId.Unknown {
+		return
+	}
+
+	_, err := library.Read(o.FilmId.Value)
+	switch {
+	case isNotExist(err):
+		diags.AddAttributeError(path.Root("film_id"), "film not found",
+			fmt.Sprintf("no film %q in %q", o.FilmId.Value, library.Dir()))
+	case err != nil:
+		diags.AddAttributeError(path.Root("film_id"), "error reading film", err.Error())
+	}
+}
+
+// viewing returns the viewing described by a plan
+func (o viewingData) viewing() *Viewing {
+	return &Viewing{
+		Id:        o.Id.Value,
+		FilmId:    o.FilmId.Value,
+		ImdbId:    o.ImdbId.Value,
+		Date:      o.Date.Value,
+		Location:  o.Location.Value,
+		Attendees: o.Attendees,
+	}
+}
+
+var _ resource.Resource = &ResourceViewing{}
+var _ resource.ResourceWithConfigure = &ResourceViewing{}
+var _ resource.ResourceWithImportState = &ResourceViewing{}
+
+// ResourceViewing implements the resource.ResourceWithConfigure interface
+type ResourceViewing struct {
+	library *Library
+}
+
+func (r *ResourceViewing) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_viewing"
+}
+
+func (r *ResourceViewing) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	if providerData, ok := req.ProviderData.(*providerResourceData); ok {
+		r.library = NewLibrary(providerData.localDir)
+	} else {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected '%T', got: '%T'. Please report this issue to the provider developers", providerData, req.ProviderData))
+	}
+}
+
+func (r *ResourceViewing) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "This Resource records a screening of a film. Viewings are kept in an append-only " +
+			"journal, `viewings/journal.jsonl` in `local_dir`: changes and deletions are recorded as new " +
+			"entries, so the journal holds the full history of every viewing. See the `omdb_viewings` " +
+			"Data Source for totals.",
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "Unique ID",
+				Computed:            true,
+				Type:                types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown(),
+				},
+			},
+			"film_id": {
+				MarkdownDescription: "ID of the `omdb_film` watched, which must be in the library. Exactly one of `film_id` and `imdb_id` must be set.",
+				Optional:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+					schemavalidator.ExactlyOneOf(path.MatchRoot("imdb_id")),
+				},
+			},
+			"imdb_id": {
+				MarkdownDescription: "IMDb ID of the film watched, for films not in the library",
+				Optional:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"date": {
+				MarkdownDescription: "Date of the viewing as YYYY-MM-DD",
+				Required:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					dateValidator{},
+				},
+			},
+			"location": {
+				MarkdownDescription: "Where the film was shown",
+				Optional:            true,
+				Type:                types.StringType,
+				Validators: []tfsdk.AttributeValidator{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"attendees": {
+				MarkdownDescription: "Names of the people who attended",
+				Optional:            true,
+				Type:                types.SetType{ElemType: types.StringType},
+				Validators: []tfsdk.AttributeValidator{
+					setvalidator.ValuesAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+		},
+	}, diag.Diagnostics{}
+}
+
+func (r *ResourceViewing) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var plan viewingData
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.checkFilm(r.library, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	viewing := plan.viewing()
+	err := r.library.AddViewing(viewing)
+	if err != nil {
+		resp.Diagnostics.AddError("error writing viewing to journal", err.Error())
+		return
+	}
+	plan.Id = types.String{Value: viewing.Id}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "added viewing to journal", map[string]interface{}{
+		"id":  viewing.Id,
+		"dir": r.library.Dir(),
+	})
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceViewing) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state viewingData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	viewing, err := r.library.ReadViewing(state.Id.Value)
+	if err != nil {
+		if isNotExist(err) {
+			tflog.SubsystemDebug(ctx, logSubsystemFiles, "viewing not found in journal, removing viewing from state", map[string]interface{}{
+				"id":  state.Id.Value,
+				"dir": r.library.Dir(),
+			})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("error reading viewing journal", err.Error())
+		return
+	}
+
+	newState := viewingData{
+		Id:        types.String{Value: state.Id.Value},
+		FilmId:    types.String{Value: viewing.FilmId, Null: viewing.FilmId == ""},
+		ImdbId:    types.String{Value: viewing.ImdbId, Null: viewing.ImdbId == ""},
+		Date:      types.String{Value: viewing.Date},
+		Location:  types.String{Value: viewing.Location, Null: viewing.Location == ""},
+		Attendees: viewing.Attendees,
+	}
+
+	diags = resp.State.Set(ctx, &newState)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceViewing) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state viewingData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan viewingData
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Id = types.String{Value: state.Id.Value}
+
+	plan.checkFilm(r.library, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.library.UpdateViewing(plan.viewing())
+	if err != nil {
+		resp.Diagnostics.AddError("error writing viewing to journal", err.Error())
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "updated viewing in journal", map[string]interface{}{
+		"id":  plan.Id.Value,
+		"dir": r.library.Dir(),
+	})
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *ResourceViewing) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = newLogSubsystem(ctx, logSubsystemFiles)
+
+	var state viewingData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.library.RemoveViewing(state.Id.Value)
+	if err != nil && !isNotExist(err) {
+		resp.Diagnostics.AddError("delete error", err.Error())
+		return
+	}
+	tflog.SubsystemDebug(ctx, logSubsystemFiles, "removed viewing in journal", map[string]interface{}{
+		"id":  state.Id.Value,
+		"dir": r.library.Dir(),
+	})
+}
+
+// ImportState adopts a viewing recorded in the journal; the import ID is the
+// viewing's ID. Read() fills in the rest.
+func (r *ResourceViewing) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
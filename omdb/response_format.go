@@ -0,0 +1,93 @@
+package omdb
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	responseFormatJson = "json"
+	responseFormatXml  = "xml"
+
+	ratingSourceImdb       = "Internet Movie Database"
+	ratingSourceMetacritic = "Metacritic"
+)
+
+// xmlRootResponse defines what we expect from OMDb when requesting r=xml.
+// OMDb's XML film records carry the IMDb and Metacritic scores as
+// attributes rather than a list of ratings; some mirrors add the list as
+// <ratings><rating source="..." value="..."/></ratings>.
+type xmlRootResponse struct {
+	XMLName  xml.Name `xml:"root"`
+	Response string   `xml:"response,attr"`
+	Error    string   `xml:"error"`
+	Movies   []struct {
+		ImdbID     string `xml:"imdbID,attr"`
+		Title      string `xml:"title,attr"`
+		Year       string `xml:"year,attr"`
+		ImdbRating string `xml:"imdbRating,attr"`
+		Metascore  string `xml:"metascore,attr"`
+		Ratings    []struct {
+			Source string `xml:"source,attr"`
+			Value  string `xml:"value,attr"`
+		} `xml:"ratings>rating"`
+	} `xml:"movie"`
+}
+
+// decodeResponse decodes an OMDb response body in the given format into out,
+// which must be one of the API response types.
+func decodeResponse(format string, body []byte, out interface{}) error {
+	switch format {
+	case "", responseFormatJson:
+		return json.Unmarshal(body, out)
+	case responseFormatXml:
+		return decodeXmlResponse(body, out)
+	default:
+		return fmt.Errorf("unsupported response format %q", format)
+	}
+}
+
+// decodeXmlResponse decodes an XML response body into the same record the
+// JSON decoder would produce.
+func decodeXmlResponse(body []byte, out interface{}) error {
+	var root xmlRootResponse
+	err := xml.Unmarshal(body, &root)
+	if err != nil {
+		return err
+	}
+
+	errResponse := apiErrorResponse{
+		Response: root.Response,
+		Error:    root.Error,
+	}
+
+	switch o := out.(type) {
+	case *apiErrorResponse:
+		*o = errResponse
+	case *filmByIdApiResponse:
+		*o = filmByIdApiResponse{apiErrorResponse: errResponse}
+		if len(root.Movies) == 0 {
+			return nil
+		}
+		movie := root.Movies[0]
+		o.ImdbID = movie.ImdbID
+		o.Title = movie.Title
+		o.Year = movie.Year
+		for _, rating := range movie.Ratings {
+			o.Ratings = append(o.Ratings, Rating{Source: rating.Source, Value: rating.Value})
+		}
+		if len(movie.Ratings) == 0 {
+			if !isNotAvailable(movie.ImdbRating) {
+				o.Ratings = append(o.Ratings, Rating{Source: ratingSourceImdb, Value: movie.ImdbRating + "/10"})
+			}
+			if !isNotAvailable(movie.Metascore) {
+				o.Ratings = append(o.Ratings, Rating{Source: ratingSourceMetacritic, Value: movie.Metascore + "/100"})
+			}
+		}
+	default:
+		return fmt.Errorf("cannot decode XML response into %T", out)
+	}
+
+	return nil
+}
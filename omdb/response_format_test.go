@@ -0,0 +1,85 @@
+package omdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixtureServer serves testdata/response_format/<fixture> for every request,
+// choosing the .json or .xml variant according to the r= query parameter.
+func fixtureServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("r")
+		if format == "" {
+			format = responseFormatJson
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", "response_format", fixtures[format]))
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestResponseFormatsDecodeEqually(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"omdb":   {responseFormatJson: "film.json", responseFormatXml: "film.xml"},
+		"mirror": {responseFormatJson: "film.json", responseFormatXml: "film_mirror.xml"},
+	}
+
+	for name, fixtures := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := fixtureServer(t, fixtures)
+			defer server.Close()
+
+			results := make(map[string]*filmByIdApiResponse)
+			for _, format := range []string{responseFormatJson, responseFormatXml} {
+				c := newClient(server.URL, []string{"key"})
+				c.format = format
+				film, err := c.filmById(context.Background(), "tt0080455")
+				if err != nil {
+					t.Fatalf("%s: %s", format, err)
+				}
+				results[format] = film
+			}
+
+			if !reflect.DeepEqual(results[responseFormatJson], results[responseFormatXml]) {
+				t.Fatalf("JSON and XML results differ:\njson: %+v\nxml:  %+v", results[responseFormatJson], results[responseFormatXml])
+			}
+			if results[responseFormatJson].Title != "The Blues Brothers" || len(results[responseFormatJson].Ratings) != 2 {
+				t.Fatalf("unexpected result: %+v", results[responseFormatJson])
+			}
+		})
+	}
+}
+
+func TestResponseFormatsReportErrorsEqually(t *testing.T) {
+	server := fixtureServer(t, map[string]string{
+		responseFormatJson: "not_found.json",
+		responseFormatXml:  "not_found.xml",
+	})
+	defer server.Close()
+
+	messages := make(map[string]string)
+	for _, format := range []string{responseFormatJson, responseFormatXml} {
+		c := newClient(server.URL, []string{"key"})
+		c.format = format
+		_, err := c.filmById(context.Background(), "tt0000000")
+		if err == nil {
+			t.Fatalf("%s: expected an error", format)
+		}
+		messages[format] = err.Error()
+	}
+
+	if messages[responseFormatJson] != messages[responseFormatXml] {
+		t.Fatalf("JSON and XML errors differ: %q vs %q", messages[responseFormatJson], messages[responseFormatXml])
+	}
+}
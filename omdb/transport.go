@@ -0,0 +1,160 @@
+package omdb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// userAgentPrefix is combined with the provider version to form the
+	// default User-Agent header
+	userAgentPrefix = "terraform-provider-omdb/"
+
+	// unixSocketScheme in api_url indicates that the OMDb API is reached
+	// over a unix domain socket, e.g. unix:///var/run/omdb.sock
+	unixSocketScheme = "unix"
+
+	// unixSocketBaseUrl stands in for api_url when building requests which
+	// will be sent over a unix domain socket
+	unixSocketBaseUrl = "http://localhost"
+)
+
+// parseApiUrl parses and checks an api_url value. Supported schemes are
+// http, https and unix.
+func parseApiUrl(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("URL %q has no host", s)
+		}
+	case unixSocketScheme:
+		if u.Host != "" {
+			return nil, fmt.Errorf("unix socket URL %q must have an empty host, e.g. unix:///path/to/socket", s)
+		}
+		if u.Path == "" {
+			return nil, fmt.Errorf("unix socket URL %q has no socket path", s)
+		}
+	default:
+		return nil, fmt.Errorf("URL %q has unsupported scheme %q, expected one of \"http\", \"https\" or \"%s\"", s, u.Scheme, unixSocketScheme)
+	}
+
+	return u, nil
+}
+
+// requestBaseUrl returns the URL which requests should be built upon. Requests
+// bound for a unix domain socket use a placeholder host; the socket path is
+// handled by the transport.
+func requestBaseUrl(apiUrl *url.URL) string {
+	if apiUrl.Scheme == unixSocketScheme {
+		return unixSocketBaseUrl
+	}
+	return strings.TrimSuffix(apiUrl.String(), "/")
+}
+
+// userAgentTransport sets the User-Agent header on every request
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// httpClient builds the *http.Client used to reach the OMDb API at apiUrl
+// according to the provider's proxy, TLS and user agent attributes.
+func (c *providerConfig) httpClient(apiUrl *url.URL, defaultUserAgent string) (*http.Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if apiUrl.Scheme == unixSocketScheme {
+		// every connection goes to the socket, regardless of host or proxy
+		socketPath := apiUrl.Path
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	} else if !c.ProxyUrl.Null && !c.ProxyUrl.Unknown {
+		proxyUrl, err := url.Parse(c.ProxyUrl.Value)
+		if err != nil {
+			diags.AddAttributeError(path.Root("proxy_url"), "error parsing proxy URL", err.Error())
+			return nil, diags
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify.Value,
+	}
+
+	if !c.CaCertFile.Null || !c.CaCertPem.Null {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !c.CaCertFile.Null && !c.CaCertFile.Unknown {
+			pem, err := os.ReadFile(c.CaCertFile.Value)
+			if err != nil {
+				diags.AddAttributeError(path.Root("ca_cert_file"), "error reading CA certificate file", err.Error())
+				return nil, diags
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				diags.AddAttributeError(path.Root("ca_cert_file"), "error parsing CA certificate file",
+					fmt.Sprintf("no PEM encoded certificates found in %q", c.CaCertFile.Value))
+				return nil, diags
+			}
+		}
+
+		if !c.CaCertPem.Null && !c.CaCertPem.Unknown {
+			if !pool.AppendCertsFromPEM([]byte(c.CaCertPem.Value)) {
+				diags.AddAttributeError(path.Root("ca_cert_pem"), "error parsing CA certificate",
+					"no PEM encoded certificates found")
+				return nil, diags
+			}
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if !c.ClientCert.Null && !c.ClientCert.Unknown && !c.ClientKey.Null && !c.ClientKey.Unknown {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert.Value), []byte(c.ClientKey.Value))
+		if err != nil {
+			diags.AddAttributeError(path.Root("client_cert"), "error loading client certificate", err.Error())
+			return nil, diags
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	userAgent := defaultUserAgent
+	if !c.UserAgent.Null && !c.UserAgent.Unknown {
+		userAgent = c.UserAgent.Value
+	}
+
+	return &http.Client{
+		Transport: &userAgentTransport{
+			userAgent: userAgent,
+			base:      transport,
+		},
+	}, diags
+}
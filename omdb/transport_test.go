@@ -0,0 +1,317 @@
+package omdb
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testFilmResponse is an OMDb response for The Blues Brothers
+const testFilmResponse = `{"Response":"True","imdbID":"tt0080455","Title":"The Blues Brothers","Year":"1980"}`
+
+// nullProviderConfig returns a providerConfig with none of the transport
+// attributes set
+func nullProviderConfig() providerConfig {
+	return providerConfig{
+		CaCertFile: types.String{Null: true},
+		CaCertPem:  types.String{Null: true},
+		ClientCert: types.String{Null: true},
+		ClientKey:  types.String{Null: true},
+		ProxyUrl:   types.String{Null: true},
+		UserAgent:  types.String{Null: true},
+	}
+}
+
+// fetchTestFilm fetches tt0080455 from the OMDb API at apiUrl with a client
+// built from config
+func fetchTestFilm(t *testing.T, config providerConfig, apiUrl string) error {
+	t.Helper()
+
+	u, err := parseApiUrl(apiUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient, diags := config.httpClient(u, userAgentPrefix+"test")
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+
+	c := newClient(requestBaseUrl(u), []string{"key"})
+	c.httpClient = httpClient
+
+	film, err := c.filmById(context.Background(), "tt0080455")
+	if err != nil {
+		return err
+	}
+	if film.Title != "The Blues Brothers" {
+		t.Fatalf("unexpected title %q", film.Title)
+	}
+
+	return nil
+}
+
+// pemEncode returns the PEM encoding of der
+func pemEncode(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+// newTestClientCert returns a self-signed client certificate and its key,
+// PEM encoded
+func newTestClientCert(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform-provider-omdb test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pemEncode("CERTIFICATE", cert), pemEncode("EC PRIVATE KEY", keyDer)
+}
+
+func TestParseApiUrl(t *testing.T) {
+	valid := []string{
+		"https://www.omdbapi.com",
+		"http://127.0.0.1:8080/",
+		"unix:///var/run/omdb.sock",
+	}
+	for _, s := range valid {
+		if _, err := parseApiUrl(s); err != nil {
+			t.Errorf("expected %q to be valid, got %s", s, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"www.omdbapi.com",
+		"ftp://www.omdbapi.com",
+		"https:///no-host",
+		"unix://host/var/run/omdb.sock",
+		"unix://",
+	}
+	for _, s := range invalid {
+		if _, err := parseApiUrl(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
+
+func TestClientOverUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "omdb.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix domain sockets unavailable: %s", err)
+	}
+
+	var userAgent string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		_, _ = w.Write([]byte(testFilmResponse))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	apiUrl, err := parseApiUrl("unix://" + socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := nullProviderConfig()
+	httpClient, diags := config.httpClient(apiUrl, userAgentPrefix+"test")
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+
+	c := newClient(requestBaseUrl(apiUrl), []string{"key"})
+	c.httpClient = httpClient
+
+	film, err := c.filmById(context.Background(), "tt0080455")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if film.Title != "The Blues Brothers" {
+		t.Fatalf("unexpected title %q", film.Title)
+	}
+	if userAgent != userAgentPrefix+"test" {
+		t.Fatalf("unexpected user agent %q", userAgent)
+	}
+}
+
+func TestClientThroughProxy(t *testing.T) {
+	// a forward proxy receives requests for other hosts
+	var proxied *url.URL
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL
+		_, _ = w.Write([]byte(testFilmResponse))
+	}))
+	defer proxy.Close()
+
+	config := nullProviderConfig()
+	config.ProxyUrl = types.String{Value: proxy.URL}
+	err := fetchTestFilm(t, config, "http://omdb.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxied == nil || proxied.Host != "omdb.invalid" {
+		t.Fatalf("expected the request for omdb.invalid to go through the proxy, got %v", proxied)
+	}
+}
+
+func TestClientTrustsConfiguredCa(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testFilmResponse))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // expected handshake failures
+	server.StartTLS()
+	defer server.Close()
+	caPem := pemEncode("CERTIFICATE", server.Certificate().Raw)
+
+	// the test server's certificate isn't trusted by default
+	err := fetchTestFilm(t, nullProviderConfig(), server.URL)
+	if err == nil {
+		t.Fatal("expected an error from a server with an untrusted certificate")
+	}
+
+	config := nullProviderConfig()
+	config.CaCertPem = types.String{Value: caPem}
+	err = fetchTestFilm(t, config, server.URL)
+	if err != nil {
+		t.Fatalf("ca_cert_pem: %s", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(caFile, []byte(caPem), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = nullProviderConfig()
+	config.CaCertFile = types.String{Value: caFile}
+	err = fetchTestFilm(t, config, server.URL)
+	if err != nil {
+		t.Fatalf("ca_cert_file: %s", err)
+	}
+
+	config = nullProviderConfig()
+	config.InsecureSkipVerify = types.Bool{Value: true}
+	err = fetchTestFilm(t, config, server.URL)
+	if err != nil {
+		t.Fatalf("insecure_skip_verify: %s", err)
+	}
+}
+
+func TestClientPresentsClientCert(t *testing.T) {
+	var peerCerts []*x509.Certificate
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peerCerts = r.TLS.PeerCertificates
+		_, _ = w.Write([]byte(testFilmResponse))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // expected handshake failures
+	server.StartTLS()
+	defer server.Close()
+
+	config := nullProviderConfig()
+	config.CaCertPem = types.String{Value: pemEncode("CERTIFICATE", server.Certificate().Raw)}
+
+	// the server refuses connections without a client certificate
+	err := fetchTestFilm(t, config, server.URL)
+	if err == nil {
+		t.Fatal("expected an error from a server requiring a client certificate")
+	}
+
+	certPem, keyPem := newTestClientCert(t)
+	config.ClientCert = types.String{Value: certPem}
+	config.ClientKey = types.String{Value: keyPem}
+	err = fetchTestFilm(t, config, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peerCerts) != 1 || peerCerts[0].Subject.CommonName != "terraform-provider-omdb test client" {
+		t.Fatalf("expected the configured client certificate, got %v", peerCerts)
+	}
+}
+
+func TestHttpClientRejectsBadTlsAttributes(t *testing.T) {
+	apiUrl, err := parseApiUrl("https://omdb.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notPem := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(notPem, []byte("not a certificate"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		configure func(*providerConfig)
+		attribute string
+	}{
+		"ca_cert_file_missing": {
+			configure: func(c *providerConfig) { c.CaCertFile = types.String{Value: filepath.Join(t.TempDir(), "missing.pem")} },
+			attribute: "ca_cert_file",
+		},
+		"ca_cert_file_not_pem": {
+			configure: func(c *providerConfig) { c.CaCertFile = types.String{Value: notPem} },
+			attribute: "ca_cert_file",
+		},
+		"ca_cert_pem_not_pem": {
+			configure: func(c *providerConfig) { c.CaCertPem = types.String{Value: "not a certificate"} },
+			attribute: "ca_cert_pem",
+		},
+		"client_cert_not_pem": {
+			configure: func(c *providerConfig) {
+				c.ClientCert = types.String{Value: "not a certificate"}
+				c.ClientKey = types.String{Value: "not a key"}
+			},
+			attribute: "client_cert",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := nullProviderConfig()
+			tc.configure(&config)
+			_, diags := config.httpClient(apiUrl, userAgentPrefix+"test")
+			if diags.ErrorsCount() != 1 {
+				t.Fatalf("expected one error, got %v", diags)
+			}
+			errDiag, ok := diags.Errors()[0].(diag.DiagnosticWithPath)
+			if !ok || !errDiag.Path().Equal(path.Root(tc.attribute)) {
+				t.Fatalf("expected an error about %s, got %v", tc.attribute, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package omdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"time"
+)
+
+var _ tfsdk.AttributeValidator = apiUrlValidator{}
+
+// apiUrlValidator ensures that a string attribute holds a URL acceptable as
+// api_url: http, https or unix scheme. Null and unknown values are skipped.
+type apiUrlValidator struct{}
+
+func (v apiUrlValidator) Description(_ context.Context) string {
+	return "value must be an http, https or " + unixSocketScheme + " URL"
+}
+
+func (v apiUrlValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v apiUrlValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	var s types.String
+	resp.Diagnostics.Append(tfsdk.ValueAs(ctx, req.AttributeConfig, &s)...)
+	if resp.Diagnostics.HasError() || s.Null || s.Unknown {
+		return
+	}
+
+	_, err := parseApiUrl(s.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.AttributePath, "invalid OMDb API URL", err.Error())
+	}
+}
+
+var _ tfsdk.AttributeValidator = dateValidator{}
+
+// dateValidator ensures that a string attribute holds a date as YYYY-MM-DD.
+// Null and unknown values are skipped.
+type dateValidator struct{}
+
+func (v dateValidator) Description(_ context.Context) string {
+	return "value must be a date as YYYY-MM-DD"
+}
+
+func (v dateValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v dateValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	var s types.String
+	resp.Diagnostics.Append(tfsdk.ValueAs(ctx, req.AttributeConfig, &s)...)
+	if resp.Diagnostics.HasError() || s.Null || s.Unknown {
+		return
+	}
+
+	_, err := time.Parse(dateLayout, s.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.AttributePath, "invalid date",
+			fmt.Sprintf("%q is not a date as YYYY-MM-DD", s.Value))
+	}
+}
@@ -0,0 +1,199 @@
+package omdb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	watchHistoryLetterboxd = "letterboxd"
+	watchHistoryImdb       = "imdb"
+)
+
+// watchedFilm is one film from a watch history export. Empty strings and
+// a zero userRating mean the export doesn't say.
+type watchedFilm struct {
+	imdbId      string
+	title       string
+	year        string
+	userRating  int64 // 1-10
+	watchedDate string
+}
+
+// watchHistoryColumns name the columns of an export format. Where several
+// columns are named, the first non-empty one wins.
+type watchHistoryColumns struct {
+	detect      []string // columns which identify the format
+	imdbId      []string
+	title       []string
+	year        []string
+	rating      []string
+	watchedDate []string
+	// ratingScale converts the export's rating to the 1-10 scale
+	ratingScale func(string) (int64, error)
+}
+
+var watchHistoryFormats = map[string]watchHistoryColumns{
+	// Letterboxd's diary.csv, ratings.csv, watched.csv and reviews.csv.
+	// Letterboxd doesn't export IMDb IDs.
+	watchHistoryLetterboxd: {
+		detect:      []string{"Name", "Letterboxd URI"},
+		title:       []string{"Name"},
+		year:        []string{"Year"},
+		rating:      []string{"Rating"},
+		watchedDate: []string{"Watched Date", "Date"},
+		ratingScale: letterboxdRating,
+	},
+	// IMDb's ratings.csv, and list and watchlist exports
+	watchHistoryImdb: {
+		detect:      []string{"Const", "Title"},
+		imdbId:      []string{"Const"},
+		title:       []string{"Title"},
+		year:        []string{"Year"},
+		rating:      []string{"Your Rating"},
+		watchedDate: []string{"Date Rated"},
+		ratingScale: imdbRating,
+	},
+}
+
+// letterboxdRating converts a rating of 0.5 to 5 stars, in half stars
+func letterboxdRating(s string) (int64, error) {
+	stars, err := strconv.ParseFloat(s, 64)
+	if err != nil || stars < 0.5 || stars > 5 || stars*2 != float64(int64(stars*2)) {
+		return 0, fmt.Errorf("rating %q is not 0.5 to 5 stars", s)
+	}
+	return int64(stars * 2), nil
+}
+
+// imdbRating checks a rating of 1 to 10
+func imdbRating(s string) (int64, error) {
+	rating, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || rating < 1 || rating > 10 {
+		return 0, fmt.Errorf("rating %q is not 1 to 10", s)
+	}
+	return rating, nil
+}
+
+// parseWatchHistory reads a Letterboxd or IMDb CSV export, detecting which
+// from its header row. Films logged more than once, as Letterboxd diaries
+// do for rewatches, are returned once, from their most recent entry; films
+// are otherwise in the order of the export.
+func parseWatchHistory(r io.Reader) (string, []watchedFilm, error) {
+	// skip any byte order mark, which would otherwise upset quoting of the
+	// first column name
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(3); err == nil && string(bom) == "\ufeff" {
+		_, _ = buffered.Discard(3)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1 // trailing empty columns are sometimes missing
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", nil, errors.New("empty CSV file")
+		}
+		return "", nil, fmt.Errorf("error reading CSV header - %w", err)
+	}
+	columnIndex := make(map[string]int, len(header))
+	for i, name := range header {
+		columnIndex[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	format, columns, err := detectWatchHistoryFormat(columnIndex)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var films []watchedFilm
+	filmIndex := make(map[string]int) // keyed by IMDb ID, or title and year
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("error reading CSV - %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(names []string) string {
+			for _, name := range names {
+				i, ok := columnIndex[strings.ToLower(name)]
+				if ok && i < len(record) && strings.TrimSpace(record[i]) != "" {
+					return strings.TrimSpace(record[i])
+				}
+			}
+			return ""
+		}
+
+		film := watchedFilm{
+			imdbId: field(columns.imdbId),
+			title:  field(columns.title),
+			year:   field(columns.year),
+		}
+		if film.imdbId == "" && film.title == "" {
+			continue // blank line
+		}
+
+		if rating := field(columns.rating); rating != "" {
+			film.userRating, err = columns.ratingScale(rating)
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		if date := field(columns.watchedDate); date != "" {
+			watched, err := time.Parse(dateLayout, date)
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: date %q is not YYYY-MM-DD", line, date)
+			}
+			film.watchedDate = watched.Format(dateLayout)
+		}
+
+		key := film.imdbId
+		if key == "" {
+			key = strings.ToLower(film.title) + "\x00" + film.year
+		}
+		i, seen := filmIndex[key]
+		switch {
+		case !seen:
+			filmIndex[key] = len(films)
+			films = append(films, film)
+		case film.watchedDate >= films[i].watchedDate:
+			if film.userRating == 0 {
+				film.userRating = films[i].userRating
+			}
+			films[i] = film
+		}
+	}
+
+	return format, films, nil
+}
+
+// detectWatchHistoryFormat returns the export format with all of its
+// identifying columns in the header
+func detectWatchHistoryFormat(columnIndex map[string]int) (string, watchHistoryColumns, error) {
+	for _, format := range []string{watchHistoryLetterboxd, watchHistoryImdb} {
+		columns := watchHistoryFormats[format]
+		detected := true
+		for _, name := range columns.detect {
+			if _, ok := columnIndex[strings.ToLower(name)]; !ok {
+				detected = false
+			}
+		}
+		if detected {
+			return format, columns, nil
+		}
+	}
+
+	return "", watchHistoryColumns{}, errors.New("unrecognized CSV header, expected a Letterboxd export " +
+		"(with Name and Letterboxd URI columns) or an IMDb export (with Const and Title columns)")
+}
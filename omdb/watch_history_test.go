@@ -0,0 +1,87 @@
+package omdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWatchHistory(t *testing.T) {
+	testCases := map[string]struct {
+		csv    string
+		format string
+		films  []watchedFilm
+	}{
+		"letterboxd_diary": {
+			csv: "Date,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n" +
+				"2022-01-03,Brazil,1985,https://boxd.it/a,4.5,,,2022-01-02\n" +
+				"2022-02-10,Alien,1979,https://boxd.it/b,,,,2022-02-09\n" +
+				"2022-06-01,Brazil,1985,https://boxd.it/c,,Yes,,2022-05-30\n" +
+				"\"2022-07-01\",\"Heat, Director's Cut\",,https://boxd.it/d,0.5\n",
+			format: watchHistoryLetterboxd,
+			films: []watchedFilm{
+				{title: "Brazil", year: "1985", userRating: 9, watchedDate: "2022-05-30"},
+				{title: "Alien", year: "1979", watchedDate: "2022-02-09"},
+				{title: "Heat, Director's Cut", userRating: 1, watchedDate: "2022-07-01"},
+			},
+		},
+		"letterboxd_watched": {
+			csv:    "Date,Name,Year,Letterboxd URI\n2021-12-31,Ran,1985,https://boxd.it/e\n",
+			format: watchHistoryLetterboxd,
+			films:  []watchedFilm{{title: "Ran", year: "1985", watchedDate: "2021-12-31"}},
+		},
+		"imdb_ratings": {
+			csv: "\ufeffConst,Your Rating,Date Rated,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year,Genres,Num Votes,Release Date,Directors\n" +
+				"tt0088846,10,2020-04-01,Brazil,https://www.imdb.com/title/tt0088846/,movie,7.9,132,1985,\"Drama, Sci-Fi\",200000,1985-02-20,Terry Gilliam\n" +
+				"tt0078748,7,2019-11-23,Alien,https://www.imdb.com/title/tt0078748/,movie,8.5,117,1979,Horror,900000,1979-05-25,Ridley Scott\n",
+			format: watchHistoryImdb,
+			films: []watchedFilm{
+				{imdbId: "tt0088846", title: "Brazil", year: "1985", userRating: 10, watchedDate: "2020-04-01"},
+				{imdbId: "tt0078748", title: "Alien", year: "1979", userRating: 7, watchedDate: "2019-11-23"},
+			},
+		},
+		"imdb_watchlist": {
+			csv: "Position,Const,Created,Modified,Description,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year,Genres,Num Votes,Release Date,Directors,Your Rating,Date Rated\n" +
+				"1,tt0089881,2021-01-01,2021-01-01,,Ran,https://www.imdb.com/title/tt0089881/,movie,8.2,162,1985,Drama,130000,1985-06-01,Akira Kurosawa,,\n",
+			format: watchHistoryImdb,
+			films:  []watchedFilm{{imdbId: "tt0089881", title: "Ran", year: "1985"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			format, films, err := parseWatchHistory(strings.NewReader(tc.csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tc.format {
+				t.Fatalf("expected format %q, got %q", tc.format, format)
+			}
+			if !reflect.DeepEqual(films, tc.films) {
+				t.Fatalf("expected %+v, got %+v", tc.films, films)
+			}
+		})
+	}
+}
+
+func TestParseWatchHistoryErrors(t *testing.T) {
+	testCases := map[string]struct {
+		csv   string
+		error string
+	}{
+		"empty":          {csv: "", error: "empty CSV file"},
+		"unknown_header": {csv: "Film,Seen\nBrazil,yes\n", error: "unrecognized CSV header"},
+		"star_rating":    {csv: "Date,Name,Year,Letterboxd URI,Rating\n2022-01-01,Brazil,1985,u,4.2\n", error: "line 2: rating \"4.2\" is not 0.5 to 5 stars"},
+		"imdb_rating":    {csv: "Const,Title,Your Rating\ntt0088846,Brazil,11\n", error: "line 2: rating \"11\" is not 1 to 10"},
+		"date":           {csv: "Const,Title,Date Rated\ntt0088846,Brazil,01/04/2020\n", error: "line 2: date \"01/04/2020\" is not YYYY-MM-DD"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := parseWatchHistory(strings.NewReader(tc.csv))
+			if err == nil || !strings.Contains(err.Error(), tc.error) {
+				t.Fatalf("expected error containing %q, got %v", tc.error, err)
+			}
+		})
+	}
+}
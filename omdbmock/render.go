@@ -0,0 +1,121 @@
+package omdbmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// writeError writes an OMDb style error response
+func writeError(w http.ResponseWriter, format string, status int, message string) {
+	writeResponse(w, format, status, errorRecord(message))
+}
+
+// writeResponse writes body as JSON, or as XML when format is "xml"
+func writeResponse(w http.ResponseWriter, format string, status int, body record) {
+	var data []byte
+	if format == "xml" {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		data = renderXml(body)
+	} else {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		data, _ = json.Marshal(body)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// renderXml renders a response the way OMDb does when asked for r=xml: a
+// <root> element whose children carry the record's string fields as
+// attributes.
+func renderXml(body record) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	rootAttrs := record{"response": body.get("Response")}
+	var children []record
+	childName := "movie"
+
+	switch {
+	case body.get("Response") == "False":
+		buf.WriteString(`<root response="False"><error>`)
+		_ = xml.EscapeText(&buf, []byte(body.get("Error")))
+		buf.WriteString(`</error></root>`)
+		return buf.Bytes()
+	case body["Search"] != nil:
+		rootAttrs["totalResults"] = body.get("totalResults")
+		childName = "result"
+		children = asRecords(body["Search"])
+	case body["Episodes"] != nil:
+		for _, k := range []string{"Title", "Season", "totalSeasons"} {
+			rootAttrs[k] = body.get(k)
+		}
+		childName = "episode"
+		children = asRecords(body["Episodes"])
+	default:
+		children = []record{body}
+	}
+
+	buf.WriteString("<root")
+	writeXmlAttrs(&buf, rootAttrs)
+	buf.WriteString(">")
+	for _, child := range children {
+		buf.WriteString("<" + childName)
+		writeXmlAttrs(&buf, child)
+		buf.WriteString("/>")
+	}
+	buf.WriteString("</root>")
+
+	return buf.Bytes()
+}
+
+func asRecords(v interface{}) []record {
+	list, _ := v.([]interface{})
+	result := make([]record, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// writeXmlAttrs writes the string fields of rec (other than Response) as
+// XML attributes, in a stable order
+func writeXmlAttrs(buf *bytes.Buffer, rec record) {
+	keys := make([]string, 0, len(rec))
+	for k, v := range rec {
+		if _, ok := v.(string); ok && k != "Response" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	if v, ok := rec["response"]; ok {
+		buf.WriteString(` response="`)
+		_ = xml.EscapeText(buf, []byte(v.(string)))
+		buf.WriteString(`"`)
+	}
+
+	for _, k := range keys {
+		if k == "response" {
+			continue
+		}
+		buf.WriteString(" " + xmlAttrName(k) + `="`)
+		_ = xml.EscapeText(buf, []byte(rec.get(k)))
+		buf.WriteString(`"`)
+	}
+}
+
+// xmlAttrName converts an OMDb JSON field name to the attribute name OMDb
+// uses in XML: the first letter is lower case (Title -> title), while
+// names which already start lower case (imdbID, totalSeasons) are kept.
+func xmlAttrName(field string) string {
+	if field == "" {
+		return field
+	}
+	return strings.ToLower(field[:1]) + field[1:]
+}
@@ -0,0 +1,385 @@
+// Package omdbmock implements an OMDb compatible HTTP server which answers
+// from a directory of fixture files rather than a real film database. It
+// exists so that the provider can be developed and tested without an API key
+// or network access: point the provider's api_url at it.
+//
+// Each fixture is a file named <imdbID>.json holding the record OMDb returns
+// for a lookup by ID (as produced by https://www.omdbapi.com/?i=<imdbID>).
+// Episodes are recognized by their seriesID, Season and Episode fields.
+// The server supports these queries:
+//
+//	i=<imdbID>                       lookup by ID
+//	t=<title>[&y=<year>][&type=...]  lookup by title
+//	s=<text>[&y=<year>][&type=...]   search titles, 10 results per page=
+//	i=<id>|t=<title>&Season=<n>      list a season's episodes
+//	i=<id>|t=<title>&Season=<n>&Episode=<m>  lookup an episode
+//
+// Responses are JSON unless r=xml is requested. Faults (latency, error
+// messages and HTTP status codes) may be injected for all requests or for
+// requests matching particular query parameters, either with Options or
+// with a faults.json file in the fixture directory.
+package omdbmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FaultsFileName is the optional file in the fixture directory which
+	// holds a JSON list of Fault
+	FaultsFileName = "faults.json"
+
+	searchPageSize = 10
+
+	errorNoApiKey           = "No API key provided."
+	errorInvalidApiKey      = "Invalid API key!"
+	errorIncorrectImdbId    = "Incorrect IMDb ID."
+	errorMovieNotFound      = "Movie not found!"
+	errorSeasonNotFound     = "Series or season not found!"
+	errorEpisodeNotFound    = "Series or episode not found!"
+	errorSomethingWentWrong = "Something went wrong."
+)
+
+// Options configure a Server
+type Options struct {
+	// FixtureDir is the directory holding <imdbID>.json fixture files and
+	// an optional faults.json file
+	FixtureDir string
+
+	// ApiKeys lists the API keys which the server accepts. When empty, any
+	// non-empty key is accepted.
+	ApiKeys []string
+
+	// Faults are applied, in addition to any listed in faults.json, to
+	// matching requests.
+	Faults []Fault
+}
+
+// Fault describes a misbehavior to inject into responses. The first fault
+// whose Match parameters all appear in a request (values compared without
+// regard to case) applies; a fault with no Match parameters applies to every
+// request.
+type Fault struct {
+	// Match lists query parameters the request must carry
+	Match map[string]string `json:"match,omitempty"`
+
+	// LatencyMs delays the response
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+
+	// Status, when non-zero, is the HTTP status code of the response
+	Status int `json:"status,omitempty"`
+
+	// Error, when set, replaces the response with an OMDb style error
+	// ({"Response":"False","Error":"..."})
+	Error string `json:"error,omitempty"`
+}
+
+func (f Fault) matches(r *http.Request) bool {
+	query := r.URL.Query()
+	for k, v := range f.Match {
+		if !strings.EqualFold(queryGet(query, k), v) {
+			return false
+		}
+	}
+	return true
+}
+
+// record is a fixture: an OMDb film, series or episode record
+type record map[string]interface{}
+
+func (r record) get(field string) string {
+	if s, ok := r[field].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// Server is an http.Handler which mimics the OMDb API
+type Server struct {
+	records []record // sorted by imdbID
+	byId    map[string]record
+	apiKeys map[string]bool
+	faults  []Fault
+}
+
+var _ http.Handler = &Server{}
+
+// New loads fixtures from opts.FixtureDir and returns a Server ready to
+// handle requests.
+func New(opts Options) (*Server, error) {
+	s := &Server{
+		byId:    make(map[string]record),
+		apiKeys: make(map[string]bool),
+		faults:  opts.Faults,
+	}
+
+	for _, key := range opts.ApiKeys {
+		s.apiKeys[key] = true
+	}
+
+	entries, err := os.ReadDir(opts.FixtureDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture directory - %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(opts.FixtureDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading fixture - %w", err)
+		}
+
+		if entry.Name() == FaultsFileName {
+			var faults []Fault
+			err = json.Unmarshal(data, &faults)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %q - %w", entry.Name(), err)
+			}
+			s.faults = append(s.faults, faults...)
+			continue
+		}
+
+		var rec record
+		err = json.Unmarshal(data, &rec)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing fixture %q - %w", entry.Name(), err)
+		}
+		if rec.get("imdbID") == "" {
+			rec["imdbID"] = strings.TrimSuffix(entry.Name(), ".json")
+		}
+		rec["Response"] = "True"
+
+		s.records = append(s.records, rec)
+		s.byId[strings.ToLower(rec.get("imdbID"))] = rec
+	}
+
+	sort.Slice(s.records, func(i, j int) bool {
+		return s.records[i].get("imdbID") < s.records[j].get("imdbID")
+	})
+
+	return s, nil
+}
+
+// ServeHTTP answers a single OMDb API request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := strings.ToLower(queryGet(query, "r"))
+
+	for _, fault := range s.faults {
+		if !fault.matches(r) {
+			continue
+		}
+		if fault.LatencyMs > 0 {
+			select {
+			case <-time.After(time.Duration(fault.LatencyMs) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.Error != "" || fault.Status != 0 {
+			status := fault.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			message := fault.Error
+			if message == "" {
+				message = errorSomethingWentWrong
+			}
+			writeError(w, format, status, message)
+			return
+		}
+		break
+	}
+
+	apiKey := queryGet(query, "apikey")
+	switch {
+	case apiKey == "":
+		writeError(w, format, http.StatusUnauthorized, errorNoApiKey)
+		return
+	case len(s.apiKeys) > 0 && !s.apiKeys[apiKey]:
+		writeError(w, format, http.StatusUnauthorized, errorInvalidApiKey)
+		return
+	}
+
+	status, body := s.answer(query)
+	writeResponse(w, format, status, body)
+}
+
+// answer works out the response to a query
+func (s *Server) answer(query map[string][]string) (int, record) {
+	id := queryGet(query, "i")
+	title := queryGet(query, "t")
+	search := queryGet(query, "s")
+	year := queryGet(query, "y")
+	kind := queryGet(query, "type")
+	season := queryGet(query, "season")
+	episode := queryGet(query, "episode")
+
+	switch {
+	case search != "":
+		return s.search(search, year, kind, queryGet(query, "page"))
+	case id == "" && title == "":
+		return http.StatusOK, errorRecord(errorSomethingWentWrong)
+	}
+
+	var found record
+	if id != "" {
+		found = s.byId[strings.ToLower(id)]
+		if found == nil {
+			return http.StatusOK, errorRecord(errorIncorrectImdbId)
+		}
+	} else {
+		found = s.findByTitle(title, year, kind)
+		if found == nil {
+			return http.StatusOK, errorRecord(errorMovieNotFound)
+		}
+	}
+
+	switch {
+	case season != "" && episode != "":
+		return s.episode(found, season, episode)
+	case season != "":
+		return s.season(found, season)
+	}
+
+	return http.StatusOK, found
+}
+
+func (s *Server) findByTitle(title string, year string, kind string) record {
+	for _, rec := range s.records {
+		if strings.EqualFold(rec.get("Title"), title) && matchesYearAndType(rec, year, kind) {
+			return rec
+		}
+	}
+	return nil
+}
+
+func (s *Server) search(text string, year string, kind string, page string) (int, record) {
+	var results []interface{}
+	for _, rec := range s.records {
+		if rec.get("seriesID") != "" {
+			continue // OMDb doesn't return episodes in search results
+		}
+		if !strings.Contains(strings.ToLower(rec.get("Title")), strings.ToLower(text)) || !matchesYearAndType(rec, year, kind) {
+			continue
+		}
+		results = append(results, map[string]interface{}{
+			"Title":  rec.get("Title"),
+			"Year":   rec.get("Year"),
+			"imdbID": rec.get("imdbID"),
+			"Type":   rec.get("Type"),
+			"Poster": rec.get("Poster"),
+		})
+	}
+
+	if len(results) == 0 {
+		return http.StatusOK, errorRecord(errorMovieNotFound)
+	}
+
+	pageNum, err := strconv.Atoi(page)
+	if err != nil || pageNum < 1 {
+		pageNum = 1
+	}
+	start := (pageNum - 1) * searchPageSize
+	if start >= len(results) {
+		return http.StatusOK, errorRecord(errorMovieNotFound)
+	}
+	end := start + searchPageSize
+	if end > len(results) {
+		end = len(results)
+	}
+
+	return http.StatusOK, record{
+		"Search":       results[start:end],
+		"totalResults": strconv.Itoa(len(results)),
+		"Response":     "True",
+	}
+}
+
+// episodesOf returns the episodes of a series in a season, ordered by
+// episode number
+func (s *Server) episodesOf(series record, season string) []record {
+	var episodes []record
+	for _, rec := range s.records {
+		if strings.EqualFold(rec.get("seriesID"), series.get("imdbID")) && rec.get("Season") == season {
+			episodes = append(episodes, rec)
+		}
+	}
+	sort.Slice(episodes, func(i, j int) bool {
+		a, _ := strconv.Atoi(episodes[i].get("Episode"))
+		b, _ := strconv.Atoi(episodes[j].get("Episode"))
+		return a < b
+	})
+	return episodes
+}
+
+func (s *Server) season(series record, season string) (int, record) {
+	episodes := s.episodesOf(series, season)
+	if len(episodes) == 0 {
+		return http.StatusOK, errorRecord(errorSeasonNotFound)
+	}
+
+	list := make([]interface{}, len(episodes))
+	for i, episode := range episodes {
+		list[i] = map[string]interface{}{
+			"Title":      episode.get("Title"),
+			"Released":   episode.get("Released"),
+			"Episode":    episode.get("Episode"),
+			"imdbRating": episode.get("imdbRating"),
+			"imdbID":     episode.get("imdbID"),
+		}
+	}
+
+	return http.StatusOK, record{
+		"Title":        series.get("Title"),
+		"Season":       season,
+		"totalSeasons": series.get("totalSeasons"),
+		"Episodes":     list,
+		"Response":     "True",
+	}
+}
+
+func (s *Server) episode(series record, season string, episode string) (int, record) {
+	for _, rec := range s.episodesOf(series, season) {
+		if rec.get("Episode") == episode {
+			return http.StatusOK, rec
+		}
+	}
+	return http.StatusOK, errorRecord(errorEpisodeNotFound)
+}
+
+func matchesYearAndType(rec record, year string, kind string) bool {
+	if year != "" && !strings.HasPrefix(rec.get("Year"), year) {
+		return false
+	}
+	if kind != "" && !strings.EqualFold(rec.get("Type"), kind) {
+		return false
+	}
+	return true
+}
+
+func errorRecord(message string) record {
+	return record{"Response": "False", "Error": message}
+}
+
+// queryGet returns a query parameter, matching its name without regard to
+// case as OMDb does (e.g. Season and season)
+func queryGet(query map[string][]string, name string) string {
+	for k, v := range query {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package omdbmock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+	opts.FixtureDir = "testdata"
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, server *httptest.Server, query url.Values) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := http.Get(server.URL + "/?" + query.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body map[string]interface{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestServerLookups(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	testCases := map[string]struct {
+		query url.Values
+		field string
+		want  string
+	}{
+		"by_id":          {query: url.Values{"i": {"tt0080455"}}, field: "Title", want: "The Blues Brothers"},
+		"by_title":       {query: url.Values{"t": {"the terminator"}}, field: "imdbID", want: "tt0088247"},
+		"by_title_year":  {query: url.Values{"t": {"The Terminator"}, "y": {"1999"}}, field: "Error", want: errorMovieNotFound},
+		"unknown_id":     {query: url.Values{"i": {"tt9999999"}}, field: "Error", want: errorIncorrectImdbId},
+		"search":         {query: url.Values{"s": {"the"}}, field: "totalResults", want: "2"},
+		"search_type":    {query: url.Values{"s": {"o"}, "type": {"series"}}, field: "totalResults", want: "1"},
+		"search_none":    {query: url.Values{"s": {"zzz"}}, field: "Error", want: errorMovieNotFound},
+		"season":         {query: url.Values{"i": {"tt0944947"}, "Season": {"1"}}, field: "totalSeasons", want: "8"},
+		"season_missing": {query: url.Values{"i": {"tt0944947"}, "Season": {"9"}}, field: "Error", want: errorSeasonNotFound},
+		"episode":        {query: url.Values{"t": {"Game of Thrones"}, "Season": {"1"}, "Episode": {"2"}}, field: "imdbID", want: "tt1668746"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tc.query.Set("apikey", "key")
+			status, body := get(t, server, tc.query)
+			if status != http.StatusOK {
+				t.Fatalf("unexpected status %d", status)
+			}
+			if body[tc.field] != tc.want {
+				t.Fatalf("expected %s=%q, got %v", tc.field, tc.want, body)
+			}
+		})
+	}
+}
+
+func TestServerSeasonEpisodesOrdered(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	_, body := get(t, server, url.Values{"i": {"tt0944947"}, "Season": {"1"}, "apikey": {"key"}})
+	episodes, _ := body["Episodes"].([]interface{})
+	if len(episodes) != 2 {
+		t.Fatalf("expected 2 episodes, got %v", body)
+	}
+	if episodes[0].(map[string]interface{})["Title"] != "Winter Is Coming" {
+		t.Fatalf("episodes out of order: %v", episodes)
+	}
+}
+
+func TestServerApiKeys(t *testing.T) {
+	server := newTestServer(t, Options{ApiKeys: []string{"good"}})
+
+	status, body := get(t, server, url.Values{"i": {"tt0080455"}})
+	if status != http.StatusUnauthorized || body["Error"] != errorNoApiKey {
+		t.Fatalf("missing key: unexpected %d %v", status, body)
+	}
+
+	status, body = get(t, server, url.Values{"i": {"tt0080455"}, "apikey": {"bad"}})
+	if status != http.StatusUnauthorized || body["Error"] != errorInvalidApiKey {
+		t.Fatalf("bad key: unexpected %d %v", status, body)
+	}
+
+	status, _ = get(t, server, url.Values{"i": {"tt0080455"}, "apikey": {"good"}})
+	if status != http.StatusOK {
+		t.Fatalf("good key: unexpected status %d", status)
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	server := newTestServer(t, Options{
+		Faults: []Fault{{Match: map[string]string{"i": "tt0088247"}, LatencyMs: 50}},
+	})
+
+	// from faults.json
+	status, body := get(t, server, url.Values{"i": {"tt0000042"}, "apikey": {"key"}})
+	if status != http.StatusUnauthorized || body["Error"] != "Request limit reached!" {
+		t.Fatalf("unexpected %d %v", status, body)
+	}
+
+	status, _ = get(t, server, url.Values{"i": {"tt0000503"}, "apikey": {"key"}})
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", status)
+	}
+
+	start := time.Now()
+	status, _ = get(t, server, url.Values{"i": {"tt0088247"}, "apikey": {"key"}})
+	if status != http.StatusOK || time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("expected delayed success, got %d after %s", status, time.Since(start))
+	}
+}
+
+func TestServerXml(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	resp, err := http.Get(server.URL + "/?i=tt0080455&r=xml&apikey=key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{`<root response="True">`, `title="The Blues Brothers"`, `imdbID="tt0080455"`, `metascore="60"`} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("expected %q in %s", want, body)
+		}
+	}
+}
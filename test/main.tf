@@ -6,11 +6,8 @@ terraform {
   }
 }
 
-variable "api_key" {}
-
 provider "omdb" {
-  // set with shell command: export TF_VAR_api_key="xxxxxx"
-  api_key = var.api_key
+  // api_key read from environment: export OMDB_API_KEY="xxxxxx"
 }
 
 data "omdb_film_by_id" "my_favorite_film" {